@@ -1,12 +1,16 @@
 package goquery
 
 import (
+	"errors"
+	"math"
+
 	"golang.org/x/net/html"
 )
 
 // First reduces the set of matched elements to the first in the set.
 // It returns a new Selection object, and an empty Selection object if the
 // the selection is empty.
+// After a Find, FindFirst is faster as it stops at the first match.
 func (s *Selection) First() *Selection {
 	return s.Eq(0)
 }
@@ -22,6 +26,8 @@ func (s *Selection) Last() *Selection {
 // If a negative index is given, it counts backwards starting at the end of the
 // set. It returns a new Selection object, and an empty Selection object if the
 // index is invalid.
+// After a Find, FindN(selector, index+1).Eq(index) is faster for a positive
+// index as it stops at the index-th match.
 func (s *Selection) Eq(index int) *Selection {
 	if index < 0 {
 		index += len(s.Nodes)
@@ -34,21 +40,58 @@ func (s *Selection) Eq(index int) *Selection {
 	return s.Slice(index, index+1)
 }
 
+// ToEnd is a special index value that can be used as end index in a call
+// to Slice so that all elements are selected until the end of the Selection.
+// It is equivalent to passing (*Selection).Length().
+const ToEnd = math.MaxInt32
+
+// ErrIndexOutOfRange is returned by SliceE when the requested range is not
+// within the Selection.
+var ErrIndexOutOfRange = errors.New("goquery: index out of range")
+
 // Slice reduces the set of matched elements to a subset specified by a range
-// of indices.
+// of indices. The start index is 0-based and indicates the index of the first
+// element to select. The end index is 0-based and indicates the index at
+// which the elements stop being selected (the end index is not selected).
+//
+// Like jQuery's slice(), negative indices count backwards starting at the end
+// of the set, and the indices are clamped to the bounds of the set, so it
+// never panics. An empty Selection object is returned if the resulting start
+// index is not before the end index. Use ToEnd as end index to select all
+// elements until the end, and SliceE to get an error instead of clamping.
 func (s *Selection) Slice(start, end int) *Selection {
+	start = clampIndex(start, len(s.Nodes))
+	end = clampIndex(end, len(s.Nodes))
+	if start >= end {
+		return pushStack(s, nil)
+	}
+	return pushStack(s, s.Nodes[start:end])
+}
+
+// SliceE is like Slice, except that it returns ErrIndexOutOfRange instead of
+// clamping the indices if they are not within the bounds of the set once the
+// negative indices are resolved, or if the start index is after the end
+// index. ToEnd is accepted as end index.
+func (s *Selection) SliceE(start, end int) (*Selection, error) {
+	l := len(s.Nodes)
 	if start < 0 {
-		start += len(s.Nodes)
+		start += l
 	}
-	if end < 0 {
-		end += len(s.Nodes)
+	if end == ToEnd {
+		end = l
+	} else if end < 0 {
+		end += l
 	}
-	return pushStack(s, s.Nodes[start:end])
+	if start < 0 || end > l || start > end {
+		return nil, ErrIndexOutOfRange
+	}
+	return pushStack(s, s.Nodes[start:end]), nil
 }
 
 // Get retrieves the underlying node at the specified index.
 // Get without parameter is not implemented, since the node array is available
-// on the Selection object.
+// on the Selection object. It panics if the index is out of range, use GetOk
+// if that may be the case.
 func (s *Selection) Get(index int) *html.Node {
 	if index < 0 {
 		index += len(s.Nodes) // Negative index gets from the end
@@ -56,6 +99,19 @@ func (s *Selection) Get(index int) *html.Node {
 	return s.Nodes[index]
 }
 
+// GetOk retrieves the underlying node at the specified index. If a negative
+// index is given, it counts backwards starting at the end of the set. The
+// boolean is false, and the node nil, if the index is out of range.
+func (s *Selection) GetOk(index int) (*html.Node, bool) {
+	if index < 0 {
+		index += len(s.Nodes)
+	}
+	if index < 0 || index >= len(s.Nodes) {
+		return nil, false
+	}
+	return s.Nodes[index], true
+}
+
 // Index returns the position of the first element within the Selection object
 // relative to its sibling elements.
 func (s *Selection) Index() int {
@@ -70,7 +126,7 @@ func (s *Selection) Index() int {
 // not found.
 func (s *Selection) IndexSelector(selector string) int {
 	if len(s.Nodes) > 0 {
-		sel := s.document.Find(selector)
+		sel := s.document.FindMatcher(compileMatcher(selector, s))
 		return indexInSlice(sel.Nodes, s.Nodes[0])
 	}
 	return -1
@@ -101,3 +157,18 @@ func (s *Selection) IndexOfSelection(sel *Selection) int {
 	}
 	return -1
 }
+
+// Resolves a negative index relative to the length, and clamps the result
+// to [0, length].
+func clampIndex(index, length int) int {
+	if index < 0 {
+		index += length
+		if index < 0 {
+			return 0
+		}
+	}
+	if index > length {
+		return length
+	}
+	return index
+}
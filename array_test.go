@@ -86,8 +86,29 @@ func TestSlice(t *testing.T) {
 }
 
 func TestSliceOutOfBounds(t *testing.T) {
-	defer assertPanic(t)
-	Doc().Find(".pvk-content").Slice(2, 12)
+	sel := Doc().Find(".pvk-content").Slice(2, 12)
+	assertLength(t, sel.Nodes, 1)
+}
+
+func TestSliceToEnd(t *testing.T) {
+	sel := Doc().Find(".container-fluid").Slice(1, ToEnd)
+	assertLength(t, sel.Nodes, 3)
+	assertSelectionIs(t, sel, "#cf2", "#cf3", "#cf4")
+}
+
+func TestSliceStartAfterEnd(t *testing.T) {
+	sel := Doc().Find(".container-fluid").Slice(3, 1)
+	assertLength(t, sel.Nodes, 0)
+}
+
+func TestSliceStartOutOfBounds(t *testing.T) {
+	sel := Doc().Find(".container-fluid").Slice(7, ToEnd)
+	assertLength(t, sel.Nodes, 0)
+}
+
+func TestSliceEmpty(t *testing.T) {
+	sel := Doc().Find("zzzz").Slice(-1, 2)
+	assertLength(t, sel.Nodes, 0)
 }
 
 func TestNegativeSliceStart(t *testing.T) {
@@ -111,8 +132,57 @@ func TestNegativeSliceBoth(t *testing.T) {
 }
 
 func TestNegativeSliceOutOfBounds(t *testing.T) {
-	defer assertPanic(t)
-	Doc().Find(".container-fluid").Slice(-12, -7)
+	sel := Doc().Find(".container-fluid").Slice(-12, -7)
+	assertLength(t, sel.Nodes, 0)
+}
+
+func TestNegativeSliceStartClamped(t *testing.T) {
+	sel := Doc().Find(".container-fluid").Slice(-12, 2)
+	assertLength(t, sel.Nodes, 2)
+	assertSelectionIs(t, sel, "#cf1", "#cf2")
+}
+
+func TestSliceE(t *testing.T) {
+	sel, e := Doc().Find(".container-fluid").SliceE(-3, -1)
+	if e != nil {
+		t.Fatalf("Expected no error, got %s.", e)
+	}
+	assertLength(t, sel.Nodes, 2)
+	assertSelectionIs(t, sel, "#cf2", "#cf3")
+
+	sel, e = Doc().Find(".container-fluid").SliceE(1, ToEnd)
+	if e != nil {
+		t.Fatalf("Expected no error, got %s.", e)
+	}
+	assertLength(t, sel.Nodes, 3)
+
+	sel, e = Doc().Find(".container-fluid").SliceE(2, 2)
+	if e != nil {
+		t.Fatalf("Expected no error, got %s.", e)
+	}
+	assertLength(t, sel.Nodes, 0)
+}
+
+func TestSliceEOutOfBounds(t *testing.T) {
+	cases := []struct {
+		start, end int
+	}{
+		{2, 12},
+		{-12, 2},
+		{-12, -7},
+		{3, 1},
+		{0, -5},
+		{5, ToEnd},
+	}
+	for i, c := range cases {
+		sel, e := Doc().Find(".container-fluid").SliceE(c.start, c.end)
+		if e != ErrIndexOutOfRange {
+			t.Errorf("[%d] - expected error %v, got %v", i, ErrIndexOutOfRange, e)
+		}
+		if sel != nil {
+			t.Errorf("[%d] - expected nil Selection, got %+v", i, sel)
+		}
+	}
 }
 
 func TestSliceRollback(t *testing.T) {
@@ -143,6 +213,41 @@ func TestGetInvalid(t *testing.T) {
 	sel.Get(129)
 }
 
+func TestGetOk(t *testing.T) {
+	sel := Doc().Find(".pvk-content")
+	cases := []struct {
+		index int
+		ok    bool
+		node  int
+	}{
+		{0, true, 0},
+		{2, true, 2},
+		{-1, true, 2},
+		{-3, true, 0},
+		{3, false, 0},
+		{-4, false, 0},
+		{129, false, 0},
+	}
+	for i, c := range cases {
+		node, ok := sel.GetOk(c.index)
+		if ok != c.ok {
+			t.Errorf("[%d] - expected ok to be %v, got %v", i, c.ok, ok)
+		}
+		if c.ok && node != sel.Nodes[c.node] {
+			t.Errorf("[%d] - expected node %v to be %v", i, node, sel.Nodes[c.node])
+		}
+		if !c.ok && node != nil {
+			t.Errorf("[%d] - expected nil node, got %v", i, node)
+		}
+	}
+}
+
+func TestGetOkEmpty(t *testing.T) {
+	if node, ok := Doc().Find("zzzz").GetOk(0); ok || node != nil {
+		t.Errorf("Expected no node, got %v.", node)
+	}
+}
+
 func TestIndex(t *testing.T) {
 	sel := Doc().Find(".pvk-content")
 	if i := sel.Index(); i != 1 {
@@ -18,6 +18,19 @@ func BenchmarkFind(b *testing.B) {
 	b.Logf("Find=%d", n)
 }
 
+func BenchmarkFindFirst(b *testing.B) {
+	var n int
+
+	for i := 0; i < b.N; i++ {
+		if n == 0 {
+			n = DocB().FindFirst("dd").Length()
+		} else {
+			DocB().FindFirst("dd")
+		}
+	}
+	b.Logf("FindFirst=%d", n)
+}
+
 func BenchmarkFindWithinSelection(b *testing.B) {
 	var n int
 
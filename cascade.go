@@ -0,0 +1,467 @@
+package goquery
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/html"
+)
+
+// StyleOptions configures the cascade of Selection.ComputedStyle, through
+// Document.SetStyleOptions.
+type StyleOptions struct {
+	// Viewport is the device the media queries are evaluated against. If it
+	// is the zero value, DefaultViewport is used.
+	Viewport Viewport
+	// ScriptingDisabled indicates if scripting is disabled, in which case
+	// the <noscript> elements are displayed. By default, scripting is
+	// enabled, as for the parser of the net/html package, which parses the
+	// content of <noscript> as text. It also applies to the visibility
+	// heuristics (see FilterVisible).
+	ScriptingDisabled bool
+	// LoadStylesheet returns the content of the style sheet linked by the
+	// href attribute of a <link rel="stylesheet"> element, as written in the
+	// document. If nil, or if it returns an error, the style sheet is
+	// ignored. See DirStylesheetLoader.
+	LoadStylesheet func(href string) (string, error)
+}
+
+// DirStylesheetLoader returns a function for StyleOptions.LoadStylesheet
+// that loads the style sheets from the files of the directory, the href
+// being a path relative to the directory. The query and fragment of the href
+// are ignored, and the hrefs with a scheme or a host are rejected. The path
+// can't lead outside of the directory.
+func DirStylesheetLoader(dir string) func(href string) (string, error) {
+	return func(href string) (string, error) {
+		if i := strings.IndexAny(href, "?#"); i >= 0 {
+			href = href[:i]
+		}
+		if strings.HasPrefix(href, "//") || strings.Contains(strings.SplitN(href, "/", 2)[0], ":") {
+			return "", errors.New("goquery: not a local style sheet: " + href)
+		}
+		b, e := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(path.Clean("/"+href))))
+		if e != nil {
+			return "", e
+		}
+		return string(b), nil
+	}
+}
+
+// A property supported by the cascade: its initial value and whether it is
+// inherited.
+type styleProperty struct {
+	initial   string
+	inherited bool
+}
+
+// The properties with an initial value and inheritance, as documented by
+// ComputedStyle.
+var styleProperties = map[string]styleProperty{
+	"background-color": {"transparent", false},
+	"border-style":     {"none", false},
+	"clip":             {"auto", false},
+	"color":            {"canvastext", true},
+	"cursor":           {"auto", true},
+	"direction":        {"ltr", true},
+	"display":          {"inline", false},
+	"float":            {"none", false},
+	"font-family":      {"serif", true},
+	"font-size":        {"medium", true},
+	"font-style":       {"normal", true},
+	"font-variant":     {"normal", true},
+	"font-weight":      {"normal", true},
+	"height":           {"auto", false},
+	"letter-spacing":   {"normal", true},
+	"line-height":      {"normal", true},
+	"list-style-type":  {"disc", true},
+	"opacity":          {"1", false},
+	"overflow":         {"visible", false},
+	"position":         {"static", false},
+	"text-align":       {"start", true},
+	"text-decoration":  {"none", false},
+	"text-indent":      {"0", true},
+	"text-transform":   {"none", true},
+	"visibility":       {"visible", true},
+	"white-space":      {"normal", true},
+	"width":            {"auto", false},
+	"word-spacing":     {"normal", true},
+	"z-index":          {"auto", false},
+}
+
+// The user agent style sheet, with the default display of the elements and
+// the default font style of some of them.
+const userAgentCSS = `
+address, article, aside, blockquote, body, center, dd, details, dialog, dir,
+div, dl, dt, fieldset, figcaption, figure, footer, form, h1, h2, h3, h4, h5,
+h6, header, hgroup, hr, html, legend, listing, main, menu, nav, ol, p,
+plaintext, pre, search, section, summary, ul, xmp { display: block }
+li { display: list-item }
+table { display: table }
+caption { display: table-caption }
+colgroup { display: table-column-group }
+col { display: table-column }
+thead { display: table-header-group }
+tbody { display: table-row-group }
+tfoot { display: table-footer-group }
+tr { display: table-row }
+td, th { display: table-cell }
+area, base, basefont, datalist, head, link, meta, noembed, noframes, param,
+rp, script, style, template, title, [hidden], input[type=hidden],
+dialog:not([open]) { display: none }
+b, strong, th, h1, h2, h3, h4, h5, h6 { font-weight: bold }
+i, em, cite, var, dfn, address { font-style: italic }
+pre, code, kbd, samp, tt { font-family: monospace }
+`
+
+// The user agent rule hiding <noscript> when scripting is enabled.
+const noscriptCSS = `noscript { display: none }`
+
+// The origins of the declarations, in increasing precedence for the normal
+// declarations. The inline styles have the author origin.
+const (
+	originUserAgent = iota
+	originAuthor
+)
+
+// The rules of the style sheets applying to a document.
+type cascade struct {
+	rules []cascadeRule
+	// authored indicates if the document has style sheets
+	authored bool
+}
+
+type cascadeRule struct {
+	sels   []cascadeSelector
+	decls  []styleDecl
+	origin int
+	order  int
+}
+
+// A selector of the selector list of a rule, and its specificity.
+type cascadeSelector struct {
+	m    Matcher
+	spec specificity
+}
+
+var (
+	userAgentOnce  sync.Once
+	userAgentRules []StyleRule
+)
+
+// ComputedStyle returns the value of the property for the first element in
+// the Selection, from the cascade of the style sheets of the document (the
+// <style> elements and the style sheets linked by <link rel="stylesheet">
+// elements, see StyleOptions), a user agent style sheet with the default
+// display of the elements, and the inline style of the element. The
+// declarations are ordered by importance (!important), origin (inline
+// styles win over the style sheets, which win over the user agent),
+// specificity and source order, and the media queries of the style sheets
+// are evaluated against the viewport of the StyleOptions of the Document.
+// The style sheets are read once and cached on the Document, until the
+// document is changed through goquery or its StyleOptions are set. As for
+// Document.Observe, the changes made directly to the html.Node values are
+// not detected.
+//
+// If no declaration applies, the properties background-color, border-style,
+// clip, display, float, height, opacity, overflow, position, text-decoration,
+// width and z-index have their initial value, and the properties color,
+// cursor, direction, font-family, font-size, font-style, font-variant,
+// font-weight, letter-spacing, line-height, list-style-type, text-align,
+// text-indent, text-transform, visibility, white-space and word-spacing are
+// inherited from the parent element, as the custom properties (--name). The
+// other properties have no initial value. The inherit, initial and unset
+// keywords are supported. The values are returned as declared: the relative
+// values such as em are not converted, and the shorthand properties such as
+// font are not expanded.
+//
+// It returns an empty string if the Selection is empty or its first node is
+// not an element.
+func (s *Selection) ComputedStyle(prop string) string {
+	if len(s.Nodes) == 0 || s.Nodes[0].Type != html.ElementNode {
+		return ""
+	}
+	n := s.Nodes[0]
+	return s.document.cascadeFor(n).computed(n, normalizeStyleName(prop))
+}
+
+// StyleOptions returns the options of the cascade of ComputedStyle for the
+// nodes of the document.
+func (d *Document) StyleOptions() StyleOptions {
+	d.styleMu.Lock()
+	defer d.styleMu.Unlock()
+	return d.styleOpts
+}
+
+// SetStyleOptions sets the options of the cascade of ComputedStyle for the
+// nodes of the document. The cached cascade is dropped, so that the style
+// sheets are read again with the new options. It is safe to call
+// concurrently with ComputedStyle.
+func (d *Document) SetStyleOptions(opts StyleOptions) {
+	d.styleMu.Lock()
+	d.styleOpts = opts
+	d.cascade = nil
+	d.styleGen++
+	d.styleMu.Unlock()
+}
+
+// Returns the cascade of the document of the node. It is cached on the
+// Document until the document is changed (see Document.stylesChanged) or
+// its StyleOptions are set, and is built again for the nodes of other
+// trees.
+func (d *Document) cascadeFor(n *html.Node) *cascade {
+	root := n
+	for root.Parent != nil {
+		root = root.Parent
+	}
+	own := d != nil && d.rootNode == root
+	var opts StyleOptions
+	var gen int
+	if own {
+		d.styleMu.Lock()
+		c := d.cascade
+		opts, gen = d.styleOpts, d.styleGen
+		d.styleMu.Unlock()
+		if c != nil {
+			return c
+		}
+	}
+	if opts.Viewport == (Viewport{}) {
+		opts.Viewport = DefaultViewport
+	}
+
+	// The cascade is built without holding the lock, as LoadStylesheet may
+	// use the document
+	c := buildCascade(root, opts)
+	if own {
+		d.styleMu.Lock()
+		if d.styleGen == gen {
+			d.cascade = c
+		}
+		d.styleMu.Unlock()
+	}
+	return c
+}
+
+// Drops the cached cascade of the document, as the change of its tree may
+// have changed its style sheets. The document may be nil, for Selections
+// not tied to a document.
+func (d *Document) stylesChanged() {
+	if d == nil {
+		return
+	}
+	d.styleMu.Lock()
+	d.cascade = nil
+	d.styleGen++
+	d.styleMu.Unlock()
+}
+
+// Builds the cascade of the style sheets of the tree of root.
+func buildCascade(root *html.Node, opts StyleOptions) *cascade {
+	userAgentOnce.Do(func() {
+		userAgentRules = ParseStylesheet(userAgentCSS).Rules
+	})
+	// The pseudo-classes of the selectors are called without holding the
+	// lock of the registry. They are not bound to the document, so that
+	// :visible does not depend on the cascade being built.
+	reg := DefaultSelectorRegistry.snapshot(nil)
+	sources := styleSources(root)
+	c := &cascade{authored: len(sources) > 0}
+	c.addRules(reg, userAgentRules, originUserAgent, opts.Viewport)
+	if !opts.ScriptingDisabled {
+		c.addRules(reg, ParseStylesheet(noscriptCSS).Rules, originUserAgent, opts.Viewport)
+	}
+	for _, src := range sources {
+		if !opts.Viewport.MatchMedia(src.media) {
+			continue
+		}
+		css := src.css
+		if src.href != "" {
+			if opts.LoadStylesheet == nil {
+				continue
+			}
+			var e error
+			if css, e = opts.LoadStylesheet(src.href); e != nil {
+				continue
+			}
+		}
+		c.addRules(reg, ParseStylesheet(css).Rules, originAuthor, opts.Viewport)
+	}
+	return c
+}
+
+// A style sheet of a document: the content of a <style> element, or the
+// href of a <link rel="stylesheet"> element, and its media attribute.
+type styleSource struct {
+	css, href, media string
+}
+
+// Returns the style sheets of the document, in document order.
+func styleSources(root *html.Node) (sources []styleSource) {
+	for n := root; n != nil; n = nextInTree(n, root) {
+		if n.Type != html.ElementNode || n.Namespace != "" {
+			continue
+		}
+		media, _ := getAttributeValue("media", n)
+		switch n.Data {
+		case "style":
+			if typ, ok := getAttributeValue("type", n); ok && typ != "" && !strings.EqualFold(typ, "text/css") {
+				continue
+			}
+			var buf bytes.Buffer
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				if c.Type == html.TextNode {
+					buf.WriteString(c.Data)
+				}
+			}
+			sources = append(sources, styleSource{css: buf.String(), media: media})
+		case "link":
+			rel, _ := getAttributeValue("rel", n)
+			href, _ := getAttributeValue("href", n)
+			rels := strings.Fields(strings.ToLower(rel))
+			if href = strings.TrimSpace(href); href != "" &&
+				isClassInSlice(rels, "stylesheet") && !isClassInSlice(rels, "alternate") {
+				sources = append(sources, styleSource{href: href, media: media})
+			}
+		}
+	}
+	return
+}
+
+// Adds the rules whose media queries match the viewport. The rules with an
+// invalid selector are dropped, as browsers do.
+func (c *cascade) addRules(reg *SelectorRegistry, rules []StyleRule, origin int, v Viewport) {
+rules:
+	for _, r := range rules {
+		for _, m := range r.Media {
+			if !v.MatchMedia(m) {
+				continue rules
+			}
+		}
+
+		cr := cascadeRule{origin: origin, order: len(c.rules)}
+		for _, g := range splitSelectorGroups(r.Selector) {
+			spec, e := complexSpecificity(g)
+			if e != nil {
+				continue rules
+			}
+			m, e := reg.compile(g, nil, nil, false)
+			if e != nil {
+				continue rules
+			}
+			cr.sels = append(cr.sels, cascadeSelector{m, spec})
+		}
+		for _, d := range r.Declarations {
+			cr.decls = append(cr.decls, styleDecl{name: d.Property, value: d.Value, important: d.Important})
+		}
+		c.rules = append(c.rules, cr)
+	}
+}
+
+// Returns the computed value of the property for the element.
+func (c *cascade) computed(n *html.Node, prop string) string {
+	val := c.cascaded(n, prop)
+	p, known := styleProperties[prop]
+	if !known && strings.HasPrefix(prop, "--") {
+		p.inherited = true
+	}
+
+	switch strings.ToLower(val) {
+	case "initial":
+		return p.initial
+	case "inherit":
+		return c.inherited(n, prop, p)
+	case "", "unset", "revert", "revert-layer":
+		if p.inherited {
+			return c.inherited(n, prop, p)
+		}
+		return p.initial
+	}
+	return val
+}
+
+// Returns the computed value of the property for the parent element, or its
+// initial value for the root element.
+func (c *cascade) inherited(n *html.Node, prop string, p styleProperty) string {
+	if n.Parent == nil || n.Parent.Type != html.ElementNode {
+		return p.initial
+	}
+	return c.computed(n.Parent, prop)
+}
+
+// Returns the value of the declaration of the property that wins the
+// cascade for the element, or an empty string.
+func (c *cascade) cascaded(n *html.Node, prop string) string {
+	var (
+		best    *styleDecl
+		bestKey cascadeKey
+	)
+	consider := func(d *styleDecl, key cascadeKey) {
+		if best == nil || bestKey.less(key) {
+			best, bestKey = d, key
+		}
+	}
+
+	for i := range c.rules {
+		r := &c.rules[i]
+		j := findStyleDecl(r.decls, prop)
+		if j < 0 {
+			continue
+		}
+		spec, ok := r.matchSpecificity(n)
+		if !ok {
+			continue
+		}
+		consider(&r.decls[j], cascadeKey{r.decls[j].important, r.origin, false, spec, r.order})
+	}
+
+	decls, _ := getStyleAndAttr(n, false)
+	if j := findStyleDecl(decls, prop); j >= 0 {
+		consider(&decls[j], cascadeKey{decls[j].important, originAuthor, true, specificity{}, len(c.rules)})
+	}
+
+	if best == nil {
+		return ""
+	}
+	return best.value
+}
+
+// Returns the specificity of the most specific selector of the rule that
+// matches the element, and false if none matches.
+func (r *cascadeRule) matchSpecificity(n *html.Node) (spec specificity, ok bool) {
+	for _, s := range r.sels {
+		if s.m.Match(n) && (!ok || spec.less(s.spec)) {
+			spec, ok = s.spec, true
+		}
+	}
+	return
+}
+
+// The precedence of a declaration in the cascade.
+type cascadeKey struct {
+	important bool
+	origin    int
+	inline    bool
+	spec      specificity
+	order     int
+}
+
+func (k cascadeKey) less(o cascadeKey) bool {
+	switch {
+	case k.important != o.important:
+		return o.important
+	case k.origin != o.origin:
+		// The origins are reversed for the !important declarations
+		return (k.origin < o.origin) != k.important
+	case k.inline != o.inline:
+		// The inline styles win over the style sheets
+		return o.inline
+	case k.spec != o.spec:
+		return k.spec.less(o.spec)
+	}
+	return k.order < o.order
+}
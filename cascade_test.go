@@ -0,0 +1,230 @@
+package goquery
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const cascadeHtml = `<html><head>
+<style>
+body { color: black; font-family: Arial }
+p { color: gray }
+#main p.note { color: green }
+p.note { color: blue !important; display: inline-block }
+.hidden { display: none }
+@media (max-width: 600px) { .wide { display: none } }
+</style>
+<style media="print">p { color: purple }</style>
+<link rel="stylesheet" href="site.css">
+<link rel="alternate stylesheet" href="alt.css">
+</head><body><div id="main"><p class="note" style="color: red">a</p><p id="p2" style="color: red">b <em>c</em></p>
+<span class="wide">d</span><span class="hidden" style="display: inline">e</span><span class="x">f</span><p id="p3">g</p></div></body></html>`
+
+func TestComputedStyle(t *testing.T) {
+	doc := loadString(t, cascadeHtml)
+
+	cases := []struct {
+		sel, prop, val string
+	}{
+		// !important wins over the inline style
+		{".note", "color", "blue"},
+		// The inline style wins over the style sheets
+		{"#p2", "color", "red"},
+		{"#p2", "COLOR", "red"},
+		// Inherited
+		{"#p2 em", "color", "red"},
+		{"#p2 em", "font-family", "Arial"},
+		{"#main", "color", "black"},
+		// User agent style sheet and initial values
+		{"#main", "display", "block"},
+		{"#p2 em", "display", "inline"},
+		{"#p2 em", "font-style", "italic"},
+		{"head", "display", "none"},
+		{".note", "display", "inline-block"},
+		{"#main", "background-color", "transparent"},
+		{"html", "color", "canvastext"},
+		// The inline style wins over a more specific selector
+		{".hidden", "display", "inline"},
+		// The media queries don't match the default viewport
+		{".wide", "display", "inline"},
+		{"#p3", "color", "gray"},
+		{"#p3", "margin-top", ""},
+	}
+	for _, c := range cases {
+		if got := doc.Find(c.sel).ComputedStyle(c.prop); got != c.val {
+			t.Errorf("%s %s: expected %q, got %q", c.sel, c.prop, c.val, got)
+		}
+	}
+
+	if got := doc.Find("none").ComputedStyle("color"); got != "" {
+		t.Errorf("expected an empty value for an empty selection, got %q", got)
+	}
+	if got := doc.Find("p").Contents().ComputedStyle("color"); got != "" {
+		t.Errorf("expected an empty value for a text node, got %q", got)
+	}
+}
+
+func TestComputedStyleKeywords(t *testing.T) {
+	doc := loadString(t, `<div style="color: red; width: 10px; --gap: 4px"><p style="color: initial; width: inherit">a</p><p style="visibility: hidden"><b style="visibility: unset; width: unset">b</b></p></div>`)
+
+	p := doc.Find("p").First()
+	if got := p.ComputedStyle("color"); got != "canvastext" {
+		t.Errorf("expected the initial color, got %q", got)
+	}
+	if got := p.ComputedStyle("width"); got != "10px" {
+		t.Errorf("expected the inherited width, got %q", got)
+	}
+	if got := p.ComputedStyle("--gap"); got != "4px" {
+		t.Errorf("expected the inherited custom property, got %q", got)
+	}
+	b := doc.Find("b")
+	if got := b.ComputedStyle("visibility"); got != "hidden" {
+		t.Errorf("expected the inherited visibility, got %q", got)
+	}
+	if got := b.ComputedStyle("width"); got != "auto" {
+		t.Errorf("expected the initial width, got %q", got)
+	}
+}
+
+func TestComputedStyleOptions(t *testing.T) {
+	doc := loadString(t, cascadeHtml+`<noscript>h</noscript>`)
+	wide, p3 := doc.Find(".wide"), doc.Find("#p3")
+
+	doc.SetStyleOptions(StyleOptions{Viewport: Viewport{Width: 400, Height: 800}})
+	if got := wide.ComputedStyle("display"); got != "none" {
+		t.Errorf("expected the media query to match, got %q", got)
+	}
+	doc.SetStyleOptions(StyleOptions{Viewport: Viewport{Width: 400, Height: 800, Type: "print"}})
+	if got := p3.ComputedStyle("color"); got != "purple" {
+		t.Errorf("expected the print style sheet, got %q", got)
+	}
+
+	if got := doc.Find("noscript").ComputedStyle("display"); got != "none" {
+		t.Errorf("expected noscript to be hidden, got %q", got)
+	}
+	doc.SetStyleOptions(StyleOptions{ScriptingDisabled: true})
+	if got := doc.Find("noscript").ComputedStyle("display"); got != "inline" {
+		t.Errorf("expected noscript to be displayed, got %q", got)
+	}
+
+	var loaded []string
+	doc.SetStyleOptions(StyleOptions{LoadStylesheet: func(href string) (string, error) {
+		loaded = append(loaded, href)
+		if href == "site.css" {
+			return "#main .x { color: orange }", nil
+		}
+		return "", errors.New("not found")
+	}})
+	if got := doc.Find(".x").ComputedStyle("color"); got != "orange" {
+		t.Errorf("expected the linked style sheet, got %q", got)
+	}
+	doc.Find("#p3").ComputedStyle("color")
+	if len(loaded) != 1 || loaded[0] != "site.css" {
+		t.Errorf("expected site.css to be loaded once, got %v", loaded)
+	}
+
+	// The changes of the style sheets are taken into account
+	doc.Find("style").First().SetText("span { color: olive }")
+	if got := doc.Find(".wide").ComputedStyle("color"); got != "olive" {
+		t.Errorf("expected the updated style sheet, got %q", got)
+	}
+}
+
+func TestDirStylesheetLoader(t *testing.T) {
+	dir, e := ioutil.TempDir("", "goquery")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(dir)
+	if e := ioutil.WriteFile(filepath.Join(dir, "site.css"), []byte("p { color: red }"), 0644); e != nil {
+		t.Fatal(e)
+	}
+
+	load := DirStylesheetLoader(dir)
+	for _, href := range []string{"site.css", "/site.css?v=2", "../site.css", "css/../site.css#x"} {
+		if css, e := load(href); e != nil || css != "p { color: red }" {
+			t.Errorf("%s: unexpected result %q, %v", href, css, e)
+		}
+	}
+	for _, href := range []string{"http://example.com/site.css", "//example.com/site.css", "missing.css"} {
+		if _, e := load(href); e == nil {
+			t.Errorf("%s: expected an error", href)
+		}
+	}
+}
+
+func TestComputedStyleLoaders(t *testing.T) {
+	doc := loadString(t, cascadeHtml)
+	for _, color := range []string{"red", "blue"} {
+		dir, e := ioutil.TempDir("", "goquery")
+		if e != nil {
+			t.Fatal(e)
+		}
+		defer os.RemoveAll(dir)
+		if e := ioutil.WriteFile(filepath.Join(dir, "site.css"), []byte(".x { color: "+color+" }"), 0644); e != nil {
+			t.Fatal(e)
+		}
+
+		// The loaders of the directories are the same function, with
+		// different directories
+		doc.SetStyleOptions(StyleOptions{LoadStylesheet: DirStylesheetLoader(dir)})
+		if got := doc.Find(".x").ComputedStyle("color"); got != color {
+			t.Errorf("expected the style sheet of the new loader, %q, got %q", color, got)
+		}
+	}
+	if got := doc.StyleOptions().LoadStylesheet; got == nil {
+		t.Error("expected the loader to be kept")
+	}
+}
+
+func TestComputedStyleCache(t *testing.T) {
+	doc := loadString(t, cascadeHtml)
+	loads := 0
+	doc.SetStyleOptions(StyleOptions{LoadStylesheet: func(href string) (string, error) {
+		loads++
+		return "", nil
+	}})
+
+	for _, sel := range []string{"#p2", "#p3", ".x", "em"} {
+		doc.Find(sel).ComputedStyle("color")
+	}
+	if loads != 1 {
+		t.Errorf("expected the style sheets to be loaded once, got %d", loads)
+	}
+
+	doc.Find("#p3").AddClass("hidden")
+	if got := doc.Find("#p3").ComputedStyle("display"); got != "none" {
+		t.Errorf("expected the new class to apply, got %q", got)
+	}
+	doc.Find("head").AppendHtml(`<style>#p3 { display: flex !important }</style>`)
+	if got := doc.Find("#p3").ComputedStyle("display"); got != "flex" {
+		t.Errorf("expected the new style sheet to apply, got %q", got)
+	}
+	if loads != 3 {
+		t.Errorf("expected the style sheets to be loaded again after each change, got %d", loads)
+	}
+}
+
+func TestComputedStyleParallel(t *testing.T) {
+	doc := loadString(t, cascadeHtml)
+	expected := doc.Find("#main *").Map(func(i int, s *Selection) string {
+		return s.ComputedStyle("color")
+	})
+
+	doc.Find("p").SetAttr("title", "t")
+	got, e := doc.Find("#main *").MapParallel(context.Background(), 4, func(i int, s *Selection) (string, error) {
+		return s.ComputedStyle("color"), nil
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("%d: expected %q, got %q", i, expected[i], got[i])
+		}
+	}
+}
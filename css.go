@@ -0,0 +1,352 @@
+package goquery
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Stylesheet is a parsed CSS style sheet, as returned by ParseStylesheet.
+type Stylesheet struct {
+	Rules []StyleRule
+}
+
+// StyleRule is a style rule of a Stylesheet.
+type StyleRule struct {
+	// Selector is the selector list of the rule.
+	Selector string
+	// Media are the media query lists of the @media rules enclosing the rule,
+	// from the outermost. The rule applies if they all match.
+	Media []string
+	// Declarations are the declarations of the rule, in source order.
+	Declarations []StyleDeclaration
+}
+
+// StyleDeclaration is a declaration of a StyleRule.
+type StyleDeclaration struct {
+	// Property is the property name, in lowercase except for the custom
+	// properties (--name).
+	Property string
+	// Value is the value, without the !important flag.
+	Value     string
+	Important bool
+}
+
+// ParseStylesheet parses the CSS style sheet. As browsers do, the parser is
+// tolerant: the invalid declarations are dropped, and the style rules
+// within @media rules are kept with their media queries, but the other
+// at-rules, such as @import, @font-face or @supports, are ignored.
+func ParseStylesheet(css string) *Stylesheet {
+	return &Stylesheet{parseStyleRules(css, nil)}
+}
+
+// Parses the style rules of the CSS, enclosed in the media query lists.
+func parseStyleRules(css string, media []string) (rules []StyleRule) {
+	for i := 0; i < len(css); {
+		end := scanCSS(css, i, "{;}")
+		prelude := strings.TrimSpace(stripCSSComments(css[i:end]))
+		// The HTML comment delimiters are allowed around a style sheet
+		prelude = strings.TrimSpace(strings.TrimPrefix(prelude, "<!--"))
+		prelude = strings.TrimSpace(strings.TrimPrefix(prelude, "-->"))
+		prelude = strings.TrimSpace(strings.TrimSuffix(prelude, "-->"))
+		if end >= len(css) {
+			break
+		}
+		if css[end] != '{' {
+			// An at-rule without block, such as @import, or a stray brace
+			i = end + 1
+			continue
+		}
+
+		blockEnd := matchingBrace(css, end)
+		body := css[end+1 : blockEnd]
+		switch {
+		case hasAtKeyword(prelude, "@media"):
+			inner := append(media[:len(media):len(media)], strings.TrimSpace(prelude[len("@media"):]))
+			rules = append(rules, parseStyleRules(body, inner)...)
+		case strings.HasPrefix(prelude, "@"):
+		case prelude != "":
+			if decls := parseStyle(body); len(decls) > 0 {
+				rule := StyleRule{Selector: prelude, Media: media}
+				for _, d := range decls {
+					rule.Declarations = append(rule.Declarations, StyleDeclaration{d.name, d.value, d.important})
+				}
+				rules = append(rules, rule)
+			}
+		}
+		i = blockEnd + 1
+	}
+	return
+}
+
+// Returns the index of the first of the stop characters at or after the
+// index i of the CSS that is not within a string, a comment or parentheses,
+// or the length of the CSS.
+func scanCSS(css string, i int, stop string) int {
+	depth := 0
+	var quote byte
+	for ; i < len(css); i++ {
+		c := css[i]
+		switch {
+		case c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '/' && i+1 < len(css) && css[i+1] == '*':
+			end := strings.Index(css[i+2:], "*/")
+			if end < 0 {
+				return len(css)
+			}
+			i += end + 3
+		case c == '(':
+			depth++
+		case c == ')':
+			if depth > 0 {
+				depth--
+			}
+		case depth == 0 && strings.IndexByte(stop, c) >= 0:
+			return i
+		}
+	}
+	return len(css)
+}
+
+// Returns the index of the brace closing the one at index i of the CSS, or
+// the length of the CSS if it is not closed.
+func matchingBrace(css string, i int) int {
+	depth := 0
+	for i < len(css) {
+		switch css[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+		i = scanCSS(css, i+1, "{}")
+	}
+	return len(css)
+}
+
+// Removes the comments of the CSS.
+func stripCSSComments(css string) string {
+	for {
+		i := strings.Index(css, "/*")
+		if i < 0 {
+			return css
+		}
+		end := strings.Index(css[i+2:], "*/")
+		if end < 0 {
+			return css[:i]
+		}
+		css = css[:i] + " " + css[i+2+end+2:]
+	}
+}
+
+// Checks if the prelude starts with the at-keyword, case-insensitively.
+func hasAtKeyword(prelude, kw string) bool {
+	return len(prelude) >= len(kw) && strings.EqualFold(prelude[:len(kw)], kw) &&
+		(len(prelude) == len(kw) || !isSelectorNameChar(prelude[len(kw)]))
+}
+
+// Viewport describes the device the media queries are evaluated against.
+type Viewport struct {
+	// Width and Height are the size of the viewport, in CSS pixels.
+	Width, Height float64
+	// Type is the media type, such as "screen" or "print". If empty, it is
+	// "screen".
+	Type string
+}
+
+// DefaultViewport is the viewport of a desktop screen, used when
+// StyleOptions.Viewport is the zero value.
+var DefaultViewport = Viewport{Width: 1280, Height: 800, Type: "screen"}
+
+// MatchMedia checks if the media query list, such as
+// "screen and (min-width: 600px), print", matches the viewport. An empty
+// list matches. The media types all, screen and print are supported, with
+// the not and only prefixes, and the width, height and orientation media
+// features, including their min- and max- prefixes and the range syntax,
+// such as (width >= 600px). The lengths may be in px, em or rem (16px). A
+// query with other media features or units does not match.
+func (v Viewport) MatchMedia(query string) bool {
+	if strings.TrimSpace(query) == "" {
+		return true
+	}
+	for _, q := range splitSelectorGroups(query) {
+		if v.matchMediaQuery(strings.ToLower(strings.TrimSpace(q))) {
+			return true
+		}
+	}
+	return false
+}
+
+// Evaluates a media query of a list.
+func (v Viewport) matchMediaQuery(q string) bool {
+	not := false
+	if strings.HasPrefix(q, "not ") {
+		not, q = true, strings.TrimSpace(q[len("not "):])
+	} else if strings.HasPrefix(q, "only ") {
+		q = strings.TrimSpace(q[len("only "):])
+	}
+
+	match, expectAnd := true, false
+	for q != "" {
+		if expectAnd {
+			if !strings.HasPrefix(q, "and") {
+				return false
+			}
+			q = strings.TrimSpace(q[len("and"):])
+			expectAnd = false
+			continue
+		}
+
+		if q[0] == '(' {
+			end := closingParen(q, 0)
+			if end < 0 {
+				return false
+			}
+			ok, known := v.matchMediaFeature(strings.TrimSpace(q[1:end]))
+			if !known {
+				return false
+			}
+			match = match && ok
+			q = strings.TrimSpace(q[end+1:])
+		} else {
+			end := strings.IndexAny(q, " (")
+			if end < 0 {
+				end = len(q)
+			}
+			switch typ := q[:end]; typ {
+			case "all":
+			case "screen", "print", "speech":
+				match = match && typ == v.mediaType()
+			default:
+				return false
+			}
+			q = strings.TrimSpace(q[end:])
+		}
+		expectAnd = true
+	}
+	return match != not
+}
+
+func (v Viewport) mediaType() string {
+	if v.Type == "" {
+		return "screen"
+	}
+	return strings.ToLower(v.Type)
+}
+
+// Evaluates a media feature, without its parentheses. It returns false as
+// second value if the feature is not supported.
+func (v Viewport) matchMediaFeature(f string) (match, known bool) {
+	if i := strings.IndexAny(f, "<>="); i >= 0 && !strings.Contains(f, ":") {
+		return v.matchMediaRange(f, i)
+	}
+
+	name, val := f, ""
+	if i := strings.IndexByte(f, ':'); i >= 0 {
+		name, val = strings.TrimSpace(f[:i]), strings.TrimSpace(f[i+1:])
+	}
+	if name == "orientation" {
+		orientation := "landscape"
+		if v.Height >= v.Width {
+			orientation = "portrait"
+		}
+		return val == orientation, val == "portrait" || val == "landscape"
+	}
+
+	op := "="
+	if strings.HasPrefix(name, "min-") {
+		op, name = ">=", name[len("min-"):]
+	} else if strings.HasPrefix(name, "max-") {
+		op, name = "<=", name[len("max-"):]
+	}
+	size, ok := v.mediaSize(name)
+	if !ok {
+		return false, false
+	}
+	if val == "" {
+		// A boolean feature, true if not zero
+		return op == "=" && size != 0, op == "="
+	}
+	length, ok := parseMediaLength(val)
+	if !ok {
+		return false, false
+	}
+	return compareMedia(size, op, length), true
+}
+
+// Evaluates a media feature in the range syntax, such as width >= 600px or
+// 600px < width, with the first operator at index i.
+func (v Viewport) matchMediaRange(f string, i int) (match, known bool) {
+	j := i + 1
+	if j < len(f) && f[j] == '=' {
+		j++
+	}
+	left, op, right := strings.TrimSpace(f[:i]), f[i:j], strings.TrimSpace(f[j:])
+	if strings.ContainsAny(right, "<>=") {
+		// Ranges with two operators are not supported
+		return false, false
+	}
+
+	if size, ok := v.mediaSize(left); ok {
+		length, ok := parseMediaLength(right)
+		return ok && compareMedia(size, op, length), ok
+	}
+	if size, ok := v.mediaSize(right); ok {
+		length, ok := parseMediaLength(left)
+		// Swap the operands, keeping the operator
+		return ok && compareMedia(length, op, size), ok
+	}
+	return false, false
+}
+
+func (v Viewport) mediaSize(name string) (float64, bool) {
+	switch name {
+	case "width":
+		return v.Width, true
+	case "height":
+		return v.Height, true
+	}
+	return 0, false
+}
+
+func compareMedia(a float64, op string, b float64) bool {
+	switch op {
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	}
+	return a == b
+}
+
+// Parses a length of a media feature, in px, em or rem.
+func parseMediaLength(val string) (float64, bool) {
+	unit, scale := "", 1.0
+	for _, u := range []string{"px", "rem", "em"} {
+		if strings.HasSuffix(val, u) {
+			unit = u
+			break
+		}
+	}
+	if unit == "em" || unit == "rem" {
+		scale = 16
+	}
+	f, e := strconv.ParseFloat(strings.TrimSuffix(val, unit), 64)
+	if e != nil || (unit == "" && f != 0) {
+		return 0, false
+	}
+	return f * scale, true
+}
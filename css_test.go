@@ -0,0 +1,77 @@
+package goquery
+
+import (
+	"testing"
+)
+
+func TestParseStylesheet(t *testing.T) {
+	sheet := ParseStylesheet(`<!--
+@charset "utf-8";
+@import url("x.css");
+/* comment { } */
+p, a[title="{;}"] { color: red; font-weight: bold !important; invalid }
+@font-face { font-family: x; src: url(x.woff) }
+@media screen and (min-width: 600px) {
+	.wide { display: block }
+	@media print { .nested { color: blue } }
+}
+div { background: url("a;b.png") }
+-->`)
+
+	if len(sheet.Rules) != 4 {
+		t.Fatalf("expected 4 rules, got %d: %+v", len(sheet.Rules), sheet.Rules)
+	}
+	r := sheet.Rules[0]
+	if r.Selector != `p, a[title="{;}"]` || len(r.Media) != 0 || len(r.Declarations) != 2 {
+		t.Errorf("unexpected first rule %+v", r)
+	}
+	if d := r.Declarations[1]; d.Property != "font-weight" || d.Value != "bold" || !d.Important {
+		t.Errorf("unexpected declaration %+v", d)
+	}
+	if r := sheet.Rules[1]; r.Selector != ".wide" || len(r.Media) != 1 || r.Media[0] != "screen and (min-width: 600px)" {
+		t.Errorf("unexpected media rule %+v", r)
+	}
+	if r := sheet.Rules[2]; r.Selector != ".nested" || len(r.Media) != 2 || r.Media[1] != "print" {
+		t.Errorf("unexpected nested media rule %+v", r)
+	}
+	if r := sheet.Rules[3]; r.Selector != "div" || r.Declarations[0].Value != `url("a;b.png")` {
+		t.Errorf("unexpected last rule %+v", r)
+	}
+}
+
+func TestMatchMedia(t *testing.T) {
+	v := Viewport{Width: 800, Height: 600}
+	cases := []struct {
+		query string
+		match bool
+	}{
+		{"", true},
+		{"all", true},
+		{"screen", true},
+		{"print", false},
+		{"not print", true},
+		{"only screen and (min-width: 600px)", true},
+		{"screen and (max-width: 600px)", false},
+		{"(min-width: 40em) and (max-width: 50rem)", true},
+		{"(orientation: landscape)", true},
+		{"(orientation: portrait)", false},
+		{"print, (width >= 800px)", true},
+		{"(width > 800px)", false},
+		{"(700px < width)", true},
+		{"(width)", true},
+		{"(min-width: 600)", false},
+		{"(prefers-color-scheme: dark)", false},
+		{"not (prefers-color-scheme: dark)", false},
+		{"tv", false},
+		{"SCREEN AND (MIN-WIDTH: 600PX)", true},
+	}
+	for _, c := range cases {
+		if got := v.MatchMedia(c.query); got != c.match {
+			t.Errorf("%q: expected %v, got %v", c.query, c.match, got)
+		}
+	}
+
+	if !(Viewport{Width: 800, Height: 600, Type: "print"}).MatchMedia("print and (max-width: 1000px)") {
+		t.Error("expected the print media type to match")
+	}
+}
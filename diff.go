@@ -0,0 +1,593 @@
+package goquery
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// ChangeType is the type of a Change computed by Diff.
+type ChangeType int
+
+const (
+	// ChangeInsert is a node of the new tree that has no match in the old one.
+	ChangeInsert ChangeType = iota
+	// ChangeDelete is a node of the old tree that has no match in the new one.
+	ChangeDelete
+	// ChangeMove is an element found unchanged at another position, either
+	// among its siblings or under another parent.
+	ChangeMove
+	// ChangeAttr is an attribute added, removed or changed on an element, or
+	// a change of the order of the attributes.
+	ChangeAttr
+	// ChangeText is a change of the data of a text, comment or doctype node.
+	ChangeText
+)
+
+// String returns the name of the type, as used in the text report.
+func (t ChangeType) String() string {
+	switch t {
+	case ChangeInsert:
+		return "insert"
+	case ChangeDelete:
+		return "delete"
+	case ChangeMove:
+		return "move"
+	case ChangeAttr:
+		return "attr"
+	case ChangeText:
+		return "text"
+	}
+	return "unknown"
+}
+
+// Change is a difference between two trees, as computed by Diff.
+//
+// The nodes are identified by paths similar to XPath expressions, such as
+// /html[1]/body[1]/div[2]/text()[1]: each step is the tag name of an element
+// (or text(), comment() and doctype() for the other types of nodes) and its
+// position among the siblings of the same name, starting at 1. A path
+// starting at a document node begins with /html[1], the paths of the nodes of
+// other Selections begin with the nodes of the Selection. The positions are
+// those of the compared nodes, so they ignore the whitespace-only text nodes
+// when DiffOptions.IgnoreWhitespace is set.
+type Change struct {
+	Type ChangeType
+	// Path and Node are the node in the old tree, they are empty for an
+	// insertion.
+	Path string
+	Node *html.Node
+	// NewPath and NewNode are the node in the new tree, they are empty for a
+	// deletion.
+	NewPath string
+	NewNode *html.Node
+	// Attr is the qualified name of the changed attribute, or empty if the
+	// order of the attributes changed.
+	Attr string
+	// OldValue and NewValue are the values of the attribute (empty if it is
+	// missing), the data of the node, or the space-separated names of the
+	// attributes in order.
+	OldValue string
+	NewValue string
+}
+
+// String returns the change as a line of the text report, such as:
+//
+//	delete /html[1]/body[1]/p[2]
+//	insert /html[1]/body[1]/ul[1]
+//	move /html[1]/body[1]/div[3] -> /html[1]/body[1]/div[1]
+//	attr /html[1]/body[1]/div[1] class: "a" -> "a b"
+//	text /html[1]/head[1]/title[1]/text()[1]: "Old" -> "New"
+func (c Change) String() string {
+	switch c.Type {
+	case ChangeInsert:
+		return "insert " + c.NewPath
+	case ChangeDelete:
+		return "delete " + c.Path
+	case ChangeMove:
+		return "move " + c.Path + " -> " + c.NewPath
+	case ChangeAttr:
+		name := c.Attr
+		if name == "" {
+			name = "(order)"
+		}
+		return fmt.Sprintf("attr %s %s: %q -> %q", c.Path, name, c.OldValue, c.NewValue)
+	case ChangeText:
+		return fmt.Sprintf("text %s: %q -> %q", c.Path, c.OldValue, c.NewValue)
+	}
+	return "unknown " + c.Path
+}
+
+// FormatDiff returns the text report of the changes, one change per line.
+func FormatDiff(changes []Change) string {
+	var buf bytes.Buffer
+	for _, c := range changes {
+		buf.WriteString(c.String())
+		buf.WriteByte('\n')
+	}
+	return buf.String()
+}
+
+// DiffOptions configures the comparison made by DiffOptions.Diff.
+type DiffOptions struct {
+	// IgnoreWhitespace skips the text nodes made only of whitespace.
+	IgnoreWhitespace bool
+	// IgnoreAttrOrder compares the attributes of the elements regardless of
+	// their order.
+	IgnoreAttrOrder bool
+}
+
+// Diff computes the changes between the nodes of a (the old tree) and the
+// nodes of b (the new tree), with the default options. See DiffOptions.Diff.
+func Diff(a, b *Selection) []Change {
+	return DiffOptions{}.Diff(a, b)
+}
+
+// Diff computes the changes between the nodes of a (the old tree) and the
+// nodes of b (the new tree), including their descendants. Typically, a and b
+// are two Documents.
+//
+// The children of the matching nodes are matched in the following order: the
+// elements with the same tag name and id, then the identical subtrees, and
+// then the remaining nodes by the longest common sequence of the same type
+// and tag name. The matched elements out of their relative order are
+// reported as moves, the others are compared for attribute and text changes,
+// and their children are compared in turn. The unmatched nodes are reported
+// as deletions and insertions, except the identical elements deleted at a
+// place and inserted at another, which are reported as moves.
+//
+// The changes are ordered by the position of their parent in the tree, and
+// for the same parent: the attribute or text change of the node itself, the
+// deletions, the insertions and the moves, then the changes of its children.
+func (o DiffOptions) Diff(a, b *Selection) []Change {
+	d := &differ{opts: o, hashes: make(map[*html.Node]uint64)}
+	d.diffChildren("", a.Nodes, "", b.Nodes)
+	d.detectMoves()
+	return d.changes
+}
+
+type differ struct {
+	opts    DiffOptions
+	hashes  map[*html.Node]uint64
+	changes []Change
+}
+
+// A matched node of the new tree, and whether it is identical.
+type diffMatch struct {
+	j         int
+	identical bool
+	moved     bool
+}
+
+func (d *differ) diffChildren(apath string, as []*html.Node, bpath string, bs []*html.Node) {
+	as, bs = d.filter(as), d.filter(bs)
+	apaths, bpaths := childPaths(apath, as), childPaths(bpath, bs)
+
+	matches := make([]*diffMatch, len(as))
+	matchedB := make([]bool, len(bs))
+	match := func(i, j int, identical bool) {
+		matches[i] = &diffMatch{j: j, identical: identical}
+		matchedB[j] = true
+	}
+
+	// Elements with the same tag name and id
+	ids := make(map[string]int)
+	for j, n := range bs {
+		if k := idKey(n); k != "" {
+			if _, ok := ids[k]; !ok {
+				ids[k] = j
+			}
+		}
+	}
+	for i, n := range as {
+		if k := idKey(n); k != "" {
+			if j, ok := ids[k]; ok && !matchedB[j] {
+				match(i, j, d.equal(n, bs[j]))
+			}
+		}
+	}
+
+	// Identical subtrees
+	byHash := make(map[uint64][]int)
+	for j, n := range bs {
+		if !matchedB[j] {
+			h := d.hash(n)
+			byHash[h] = append(byHash[h], j)
+		}
+	}
+	for i, n := range as {
+		if matches[i] != nil {
+			continue
+		}
+		h := d.hash(n)
+		for k, j := range byHash[h] {
+			if !matchedB[j] && d.equal(n, bs[j]) {
+				match(i, j, true)
+				// The other candidates may still match, only j is removed
+				byHash[h] = append(byHash[h][:k], byHash[h][k+1:]...)
+				break
+			}
+		}
+	}
+
+	// The remaining nodes of the same type and name, in order
+	var ra, rb []int
+	for i, m := range matches {
+		if m == nil {
+			ra = append(ra, i)
+		}
+	}
+	for j, ok := range matchedB {
+		if !ok {
+			rb = append(rb, j)
+		}
+	}
+	for _, p := range commonSequence(ra, rb, func(i, j int) bool {
+		return nodeKey(as[i]) == nodeKey(bs[j])
+	}) {
+		match(p[0], p[1], false)
+	}
+
+	// The matched elements that are out of order are moved
+	var els []int
+	for i, m := range matches {
+		if m != nil && as[i].Type == html.ElementNode {
+			els = append(els, i)
+		}
+	}
+	keep := longestIncreasing(els, func(i int) int { return matches[i].j })
+	for k, i := range els {
+		matches[i].moved = !keep[k]
+	}
+
+	for i, m := range matches {
+		if m == nil {
+			d.changes = append(d.changes, Change{Type: ChangeDelete, Path: apaths[i], Node: as[i]})
+		}
+	}
+	for j, ok := range matchedB {
+		if !ok {
+			d.changes = append(d.changes, Change{Type: ChangeInsert, NewPath: bpaths[j], NewNode: bs[j]})
+		}
+	}
+	for i, m := range matches {
+		if m != nil && m.moved {
+			d.changes = append(d.changes, Change{Type: ChangeMove, Path: apaths[i], Node: as[i],
+				NewPath: bpaths[m.j], NewNode: bs[m.j]})
+		}
+	}
+	for i, m := range matches {
+		if m != nil && !m.identical {
+			d.diffNodes(apaths[i], as[i], bpaths[m.j], bs[m.j])
+		}
+	}
+}
+
+// Compares two matched nodes, of the same type and name.
+func (d *differ) diffNodes(apath string, a *html.Node, bpath string, b *html.Node) {
+	change := Change{Path: apath, Node: a, NewPath: bpath, NewNode: b}
+
+	switch a.Type {
+	case html.ElementNode:
+		d.diffAttrs(change)
+	case html.TextNode, html.CommentNode, html.DoctypeNode:
+		if a.Data != b.Data {
+			change.Type, change.OldValue, change.NewValue = ChangeText, a.Data, b.Data
+			d.changes = append(d.changes, change)
+		}
+		return
+	}
+
+	d.diffChildren(apath, childNodes(a), bpath, childNodes(b))
+}
+
+func (d *differ) diffAttrs(change Change) {
+	a, b := change.Node, change.NewNode
+	change.Type = ChangeAttr
+
+	var acommon, bcommon []string
+	for i, at := range a.Attr {
+		name := qualifiedAttrName(at)
+		if _, dup := findAttr(a.Attr[:i], name); dup {
+			continue
+		}
+		val, ok := findAttr(b.Attr, name)
+		if ok {
+			acommon = append(acommon, name)
+		}
+		if !ok || val != at.Val {
+			change.Attr, change.OldValue, change.NewValue = name, at.Val, val
+			d.changes = append(d.changes, change)
+		}
+	}
+	for i, at := range b.Attr {
+		name := qualifiedAttrName(at)
+		if _, dup := findAttr(b.Attr[:i], name); dup {
+			continue
+		}
+		if _, ok := findAttr(a.Attr, name); ok {
+			bcommon = append(bcommon, name)
+		} else {
+			change.Attr, change.OldValue, change.NewValue = name, "", at.Val
+			d.changes = append(d.changes, change)
+		}
+	}
+
+	if !d.opts.IgnoreAttrOrder {
+		ao, bo := strings.Join(acommon, " "), strings.Join(bcommon, " ")
+		if ao != bo {
+			change.Attr, change.OldValue, change.NewValue = "", ao, bo
+			d.changes = append(d.changes, change)
+		}
+	}
+}
+
+// Turns the deletions and insertions of identical elements into moves.
+func (d *differ) detectMoves() {
+	inserted := make(map[uint64][]int)
+	for k, c := range d.changes {
+		if c.Type == ChangeInsert && c.NewNode.Type == html.ElementNode {
+			h := d.hash(c.NewNode)
+			inserted[h] = append(inserted[h], k)
+		}
+	}
+	if len(inserted) == 0 {
+		return
+	}
+
+	dropped := make(map[int]bool)
+	for k, c := range d.changes {
+		if c.Type != ChangeDelete || c.Node.Type != html.ElementNode {
+			continue
+		}
+		h := d.hash(c.Node)
+		for l, ik := range inserted[h] {
+			ins := d.changes[ik]
+			if d.equal(c.Node, ins.NewNode) {
+				d.changes[k] = Change{Type: ChangeMove, Path: c.Path, Node: c.Node,
+					NewPath: ins.NewPath, NewNode: ins.NewNode}
+				dropped[ik] = true
+				inserted[h] = append(inserted[h][:l:l], inserted[h][l+1:]...)
+				break
+			}
+		}
+	}
+
+	changes := d.changes[:0]
+	for k, c := range d.changes {
+		if !dropped[k] {
+			changes = append(changes, c)
+		}
+	}
+	d.changes = changes
+}
+
+// Returns the nodes that are compared, skipping the whitespace-only text
+// nodes if required.
+func (d *differ) filter(ns []*html.Node) []*html.Node {
+	if !d.opts.IgnoreWhitespace {
+		return ns
+	}
+	var res []*html.Node
+	for _, n := range ns {
+		if !isWhitespaceText(n) {
+			res = append(res, n)
+		}
+	}
+	return res
+}
+
+// Returns the hash of the subtree, as compared with the options.
+func (d *differ) hash(n *html.Node) uint64 {
+	if h, ok := d.hashes[n]; ok {
+		return h
+	}
+
+	f := fnv.New64a()
+	write := func(s string) {
+		f.Write([]byte(s))
+		f.Write([]byte{0})
+	}
+	write(strconv.Itoa(int(n.Type)))
+	write(n.Namespace)
+	write(n.Data)
+	for _, a := range d.attrs(n) {
+		write(qualifiedAttrName(a))
+		write(a.Val)
+	}
+	for _, c := range d.filter(childNodes(n)) {
+		write(strconv.FormatUint(d.hash(c), 16))
+	}
+
+	h := f.Sum64()
+	d.hashes[n] = h
+	return h
+}
+
+// Checks if the subtrees are identical, as compared with the options.
+func (d *differ) equal(a, b *html.Node) bool {
+	if d.hash(a) != d.hash(b) || a.Type != b.Type || a.Namespace != b.Namespace || a.Data != b.Data {
+		return false
+	}
+	aa, ba := d.attrs(a), d.attrs(b)
+	if !equalAttrs(aa, ba) {
+		return false
+	}
+	ac, bc := d.filter(childNodes(a)), d.filter(childNodes(b))
+	if len(ac) != len(bc) {
+		return false
+	}
+	for i := range ac {
+		if !d.equal(ac[i], bc[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns the attributes of the node, sorted if their order is ignored.
+func (d *differ) attrs(n *html.Node) []html.Attribute {
+	if !d.opts.IgnoreAttrOrder || len(n.Attr) < 2 {
+		return n.Attr
+	}
+	attrs := append([]html.Attribute(nil), n.Attr...)
+	sort.SliceStable(attrs, func(i, j int) bool {
+		return qualifiedAttrName(attrs[i]) < qualifiedAttrName(attrs[j])
+	})
+	return attrs
+}
+
+func childNodes(n *html.Node) []*html.Node {
+	var ns []*html.Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		ns = append(ns, c)
+	}
+	return ns
+}
+
+// Returns the paths of the nodes, children of the node at the given path.
+func childPaths(path string, ns []*html.Node) []string {
+	paths := make([]string, len(ns))
+	counts := make(map[string]int)
+	for i, n := range ns {
+		var name string
+		switch n.Type {
+		case html.DocumentNode:
+			paths[i] = "/"
+			continue
+		case html.ElementNode:
+			name = n.Data
+		case html.TextNode:
+			name = "text()"
+		case html.CommentNode:
+			name = "comment()"
+		case html.DoctypeNode:
+			name = "doctype()"
+		}
+		counts[name]++
+		paths[i] = strings.TrimSuffix(path, "/") + "/" + name + "[" + strconv.Itoa(counts[name]) + "]"
+	}
+	return paths
+}
+
+// Returns the key of the nodes that can be matched together.
+func nodeKey(n *html.Node) string {
+	if n.Type == html.ElementNode {
+		return n.Namespace + ":" + n.Data
+	}
+	return strconv.Itoa(int(n.Type))
+}
+
+// Returns the key of the elements with an id, matched with the same key.
+func idKey(n *html.Node) string {
+	if n.Type != html.ElementNode {
+		return ""
+	}
+	if id, ok := getAttributeValue("id", n); ok && id != "" {
+		return nodeKey(n) + "#" + id
+	}
+	return ""
+}
+
+func isWhitespaceText(n *html.Node) bool {
+	if n.Type != html.TextNode {
+		return false
+	}
+	for i := 0; i < len(n.Data); i++ {
+		if !isASCIIWhitespace(n.Data[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns which of the items are part of the longest increasing sequence of
+// their values.
+func longestIncreasing(items []int, val func(int) int) []bool {
+	// tails[k] is the index of the item ending the best sequence of length k+1
+	var tails []int
+	prev := make([]int, len(items))
+	for k, it := range items {
+		v := val(it)
+		l := sort.Search(len(tails), func(t int) bool { return val(items[tails[t]]) >= v })
+		if l > 0 {
+			prev[k] = tails[l-1]
+		} else {
+			prev[k] = -1
+		}
+		if l == len(tails) {
+			tails = append(tails, k)
+		} else {
+			tails[l] = k
+		}
+	}
+
+	keep := make([]bool, len(items))
+	if len(tails) > 0 {
+		for k := tails[len(tails)-1]; k >= 0; k = prev[k] {
+			keep[k] = true
+		}
+	}
+	return keep
+}
+
+// Above this number of compared pairs, commonSequence matches the items
+// greedily instead of computing the longest common sequence.
+const maxCommonSequenceCost = 1 << 20
+
+// Returns the pairs of items of the longest common sequence of a and b.
+func commonSequence(a, b []int, eq func(i, j int) bool) [][2]int {
+	var pairs [][2]int
+	if len(a) == 0 || len(b) == 0 {
+		return nil
+	}
+
+	if len(a)*len(b) > maxCommonSequenceCost {
+		j := 0
+		for _, i := range a {
+			for k := j; k < len(b); k++ {
+				if eq(i, b[k]) {
+					pairs = append(pairs, [2]int{i, b[k]})
+					j = k + 1
+					break
+				}
+			}
+		}
+		return pairs
+	}
+
+	// lens[x][y] is the length of the common sequence of a[x:] and b[y:]
+	lens := make([][]int, len(a)+1)
+	for x := range lens {
+		lens[x] = make([]int, len(b)+1)
+	}
+	for x := len(a) - 1; x >= 0; x-- {
+		for y := len(b) - 1; y >= 0; y-- {
+			if eq(a[x], b[y]) {
+				lens[x][y] = lens[x+1][y+1] + 1
+			} else if lens[x+1][y] >= lens[x][y+1] {
+				lens[x][y] = lens[x+1][y]
+			} else {
+				lens[x][y] = lens[x][y+1]
+			}
+		}
+	}
+	for x, y := 0, 0; x < len(a) && y < len(b); {
+		switch {
+		case eq(a[x], b[y]):
+			pairs = append(pairs, [2]int{a[x], b[y]})
+			x++
+			y++
+		case lens[x+1][y] >= lens[x][y+1]:
+			x++
+		default:
+			y++
+		}
+	}
+	return pairs
+}
@@ -0,0 +1,112 @@
+package goquery
+
+import (
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestDiffIdentical(t *testing.T) {
+	if changes := Diff(Doc2().Selection, Doc2Clone().Selection); len(changes) != 0 {
+		t.Errorf("expected no change, got:\n%s", FormatDiff(changes))
+	}
+}
+
+func TestDiff(t *testing.T) {
+	a := loadString(t, `<div id="main"><p class="a">one</p><p>two</p><ul><li>x</li></ul></div><div id="side"><span>s</span></div>`)
+	b := loadString(t, `<div id="side"><span>s</span></div><div id="main" title="t"><p class="b">one</p><p>2</p><ol></ol></div>`)
+
+	expected := `move /html[1]/body[1]/div[1] -> /html[1]/body[1]/div[2]
+attr /html[1]/body[1]/div[1] title: "" -> "t"
+delete /html[1]/body[1]/div[1]/ul[1]
+insert /html[1]/body[1]/div[2]/ol[1]
+attr /html[1]/body[1]/div[1]/p[1] class: "a" -> "b"
+text /html[1]/body[1]/div[1]/p[2]/text()[1]: "two" -> "2"
+`
+	if got := FormatDiff(Diff(a.Selection, b.Selection)); got != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestDiffMoveAcrossParents(t *testing.T) {
+	a := loadString(t, `<div id="d1"><p class="moved">m</p></div><div id="d2"></div>`)
+	b := loadString(t, `<div id="d1"></div><div id="d2"><p class="moved">m</p></div>`)
+
+	changes := Diff(a.Selection, b.Selection)
+	if len(changes) != 1 {
+		t.Fatalf("expected 1 change, got:\n%s", FormatDiff(changes))
+	}
+	c := changes[0]
+	if c.Type != ChangeMove || c.Path != "/html[1]/body[1]/div[1]/p[1]" || c.NewPath != "/html[1]/body[1]/div[2]/p[1]" {
+		t.Errorf("unexpected change %s", c)
+	}
+	if c.Node != a.Find("p").Get(0) || c.NewNode != b.Find("p").Get(0) {
+		t.Error("expected the nodes of the change to be set")
+	}
+}
+
+func TestDiffCrossingReorder(t *testing.T) {
+	a := loadString(t, `<div id="x">X</div><p>A</p>`)
+	b := loadString(t, `<p>B</p><div id="x">X</div>`)
+
+	// The paragraphs are matched after the identical div, which crosses them
+	expected := `move /html[1]/body[1]/div[1] -> /html[1]/body[1]/div[1]
+text /html[1]/body[1]/p[1]/text()[1]: "A" -> "B"
+`
+	if got := FormatDiff(Diff(a.Selection, b.Selection)); got != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestDiffOptions(t *testing.T) {
+	a := loadString(t, "<div>\n  <p id=\"x\" class=\"c\">t</p>\n</div>")
+	b := loadString(t, `<div><p class="c" id="x">t</p></div>`)
+
+	changes := Diff(a.Selection, b.Selection)
+	if len(changes) != 3 {
+		t.Errorf("expected 3 changes, got:\n%s", FormatDiff(changes))
+	}
+
+	changes = DiffOptions{IgnoreWhitespace: true}.Diff(a.Selection, b.Selection)
+	expected := `attr /html[1]/body[1]/div[1]/p[1] (order): "id class" -> "class id"
+`
+	if got := FormatDiff(changes); got != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+
+	changes = DiffOptions{IgnoreWhitespace: true, IgnoreAttrOrder: true}.Diff(a.Selection, b.Selection)
+	if len(changes) != 0 {
+		t.Errorf("expected no change, got:\n%s", FormatDiff(changes))
+	}
+}
+
+func TestDiffSelections(t *testing.T) {
+	a := loadString(t, `<p>a</p><p>b</p>`)
+	b := loadString(t, `<p>a</p><p>b</p><p>c</p>`)
+
+	changes := Diff(a.Find("p"), b.Find("p"))
+	if len(changes) != 1 || changes[0].String() != "insert /p[3]" {
+		t.Errorf("expected an insertion, got:\n%s", FormatDiff(changes))
+	}
+}
+
+func TestDiffHashCollision(t *testing.T) {
+	a := loadString(t, `<div><p>p</p><span class="x">x</span></div>`)
+	b := loadString(t, `<div><span class="x">x</span><p>p</p><span class="y">y</span></div>`)
+
+	// All the children collide, so that the identical subtrees are found
+	// after the candidates that are not
+	d := &differ{hashes: make(map[*html.Node]uint64)}
+	as, bs := childNodes(a.Find("div").Get(0)), childNodes(b.Find("div").Get(0))
+	for _, n := range append(as[:len(as):len(as)], bs...) {
+		d.hashes[n] = 1
+	}
+	d.diffChildren("/div[1]", as, "/div[1]", bs)
+
+	expected := `insert /div[1]/span[2]
+move /div[1]/p[1] -> /div[1]/p[1]
+`
+	if got := FormatDiff(d.changes); got != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
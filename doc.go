@@ -28,8 +28,9 @@ syntax, to manipulate and query an HTML document.
 It brings a syntax and a set of features similar to jQuery to the Go language.
 It is based on Go's net/html package and the CSS Selector library cascadia.
 Since the net/html parser returns nodes, and not a full-featured DOM
-tree, jQuery's stateful manipulation functions (like height(), css(), detach())
-have been left off.
+tree, jQuery's stateful manipulation functions (like height() or offset())
+have been left off. The inline style is plain attribute data, though, so
+css() is available as Css() and its setters.
 
 Also, because the net/html parser requires UTF-8 encoding, so does goquery: it is
 the caller's responsibility to ensure that the source document provides UTF-8 encoded HTML.
@@ -42,6 +43,14 @@ library was better to follow its API than to start anew (in the same spirit as
 Go's fmt package), even though some of its methods are less than intuitive (looking
 at you, index()...).
 
+The selector strings accepted by the methods of Selection are CSS selectors,
+compiled by cascadia. As in jQuery, a selector may also start with a combinator
+(>, + or ~), such as Find("> li"), to be relative to the nodes of the
+Selection, and the :scope pseudo-class at the start of a selector matches
+these nodes, such as Filter(":scope.active"). Custom pseudo-classes,
+implemented in Go, can be added to the selectors with
+DefaultSelectorRegistry.Register.
+
 It is hosted on GitHub, along with additional documentation in the README.md
 file: https://github.com/puerkitobio/goquery
 
@@ -53,16 +62,33 @@ The three dots (...) indicate that various "overloads" are available.
 * array.go : array-like positional manipulation of the selection.
     - Eq()
     - First()
-    - Get()
+    - Get(), GetOk()
     - Index...()
     - Last()
-    - Slice()
+    - Slice(), SliceE()
+
+* cascade.go : computed style of the elements, from the style sheets of the document.
+    - ComputedStyle()
+    - DirStylesheetLoader()
+    - Document.SetStyleOptions(), Document.StyleOptions()
+
+* css.go : parsing of CSS style sheets, and evaluation of media queries.
+    - ParseStylesheet()
+    - Viewport.MatchMedia()
+
+* diff.go : structural comparison of two trees.
+    - Diff(), DiffOptions.Diff()
+    - FormatDiff()
 
 * expand.go : methods that expand or augment the selection's set.
     - Add...()
     - AndSelf()
     - Union(), which is an alias for AddSelection()
 
+* explain.go : step-by-step evaluation of a selector, to debug why it doesn't match.
+    - Explain()
+    - Explanation.String()
+
 * filter.go : filtering methods, that reduce the selection's set.
     - End()
     - Filter...()
@@ -70,45 +96,121 @@ The three dots (...) indicate that various "overloads" are available.
     - Intersection(), which is an alias of FilterSelection()
     - Not...()
 
+* induce.go : induction of selectors from example nodes.
+    - InduceSelectors()
+
 * iteration.go : methods to loop over the selection's nodes.
     - Each()
+    - EachParallel()
     - EachWithBreak()
     - Map()
+    - MapParallel()
+
+* iteration_generic.go : generic helpers to extract typed values from the selection's nodes (Go1.18+).
+    - GroupBy()
+    - MapErr()
+    - MapTo()
+    - Reduce()
+    - ToMap()
+
+* iteration_seq.go : iterators over the selection's nodes and the tree, for use with for-range loops (Go1.23+).
+    - All()
+    - Ancestors()
+    - Descendants()
+    - FollowingSiblings()
+    - NodesSeq()
 
 * manipulation.go : methods for modifying the document
-    - After...()
-    - Append...()
-    - Before...()
+    - After...(), AfterCopy()
+    - Append...(), AppendCopy()
+    - Before...(), BeforeCopy()
     - Clone()
+    - CopyTo()
+    - Detach()
     - Empty()
+    - MoveTo()
+    - Prepend...(), PrependCopy()
     - Remove...()
     - ReplaceWith...()
+    - SetText()
     - Unwrap()
     - Wrap...()
     - WrapAll...()
     - WrapInner...()
 
 * property.go : methods that inspect and get the node's properties values.
-    - Attr(), RemoveAttr(), SetAttr()
+    - Attr(), AttrOr(), Attrs(), HasAttr()
+    - RemoveAttr(), RemoveAttrs(), RemoveAttrsMatching(), RenameAttr(), SetAttr()
     - AddClass(), HasClass(), RemoveClass(), ToggleClass()
+    - Classes(), HasAllClasses(), HasAnyClass(), ReplaceClass(), ToggleClassState()
+    - Css(), RemoveCss(), SetCss(), SetCssMap()
+    - Data(), DataJSON(), DataMap(), SetData()
+    - Href(), Id(), SelectedIndex(), TagName(), Val()
     - Html()
     - Length()
+    - Prop(), SetProp()
     - Size(), which is an alias for Length()
     - Text()
 
+* morph.go : in-place update of the document to match new content.
+    - Morph()
+
+* observe.go : observers notified of the changes made to a document.
+    - Document.Observe()
+    - Observer.AttributeFilter()
+    - Observer.Disconnect()
+    - Observer.Target()
+
 * query.go : methods that query, or reflect, a node's identity.
     - Contains()
     - Is...()
 
+* records.go : detection of repeated records, such as search results.
+    - DetectRecords()
+
+* registry.go : custom pseudo-classes for the selector strings.
+    - NewSelectorRegistry()
+    - SelectorRegistry.Compile()
+    - SelectorRegistry.MustCompile()
+    - SelectorRegistry.Register()
+    - SelectorRegistry.Unregister()
+
+* specificity.go : specificity of the selectors, and ordering of CSS rules.
+    - SortRules()
+    - Specificity()
+
+* stream.go : incremental parsing of big documents, with handlers called for the matching elements.
+    - NewStreamer()
+    - Streamer.Handle...()
+    - Streamer.Stream()
+
 * traversal.go : methods to traverse the HTML document tree.
     - Children...()
     - Contents()
+    - Exists...()
     - Find...()
+    - FindFirst...()
+    - FindN...()
     - Next...()
     - Parent[s]...()
     - Prev...()
     - Siblings...()
 
+* tx.go : transactions recording the changes made to a document, so they can be reverted.
+    - Document.Begin()
+    - Tx.Commit()
+    - Tx.Rollback()
+
+* unique.go : generation of CSS selectors identifying a node.
+    - Path()
+    - UniqueSelector()
+
+* visible.go : visibility heuristics, to ignore the hidden content.
+    - FilterVisible...()
+    - NotHidden...()
+    - RegisterVisible()
+    - VisibleText...()
+
 * type.go : definition of the types exposed by goquery.
     - Document
     - Selection
@@ -0,0 +1,322 @@
+package goquery
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Maximum number of near misses reported for a step of an Explanation.
+const maxNearMisses = 5
+
+// Explanation is the step-by-step evaluation of a selector in a document, as
+// returned by Explain. Its String method formats it as a text report.
+type Explanation struct {
+	// Selector is the explained selector.
+	Selector string
+	// Groups are the evaluations of the selector groups, separated by commas
+	// in the selector.
+	Groups []ExplainGroup
+	// Matches is the number of elements matched by the selector.
+	Matches int
+}
+
+// ExplainGroup is the evaluation of a selector group.
+type ExplainGroup struct {
+	// Selector is the selector group.
+	Selector string
+	// Steps are the evaluations of the compound selectors of the group, from
+	// left to right.
+	Steps []ExplainStep
+}
+
+// ExplainStep is the evaluation of a compound selector of a group.
+type ExplainStep struct {
+	// Combinator is the combinator leading to the compound selector from the
+	// previous step (" " for the descendant combinator), or empty for the
+	// first step.
+	Combinator string
+	// Compound is the compound selector.
+	Compound string
+	// Candidates is the number of elements reached through the combinator
+	// from the matches of the previous step, or all the elements of the
+	// document for the first step.
+	Candidates int
+	// Matches is the number of candidates matching the compound selector.
+	Matches int
+	// NearMisses are the candidates that match some of the simple selectors
+	// of the compound selector but not all of them, starting with the ones
+	// missing the fewest.
+	NearMisses []NearMiss
+}
+
+// NearMiss is an element that almost matches a compound selector.
+type NearMiss struct {
+	// Node is the element, and Path its selector path (see Selection.Path).
+	Node *html.Node
+	Path string
+	// Matched and Failed are the simple selectors of the compound selector,
+	// such as "div" or ".item", that the element matches and fails.
+	Matched []string
+	Failed  []string
+}
+
+// Explain evaluates the selector in the document, one compound selector and
+// combinator at a time from left to right, to find out which part of the
+// selector stops matching. Each step reports the number of elements reached
+// and matched, and the elements that almost match, such as an element with
+// the expected tag name but not the expected class. The custom pseudo-classes
+// of DefaultSelectorRegistry can be used. It returns an error if the selector
+// is invalid or relative, as there is no scope.
+func Explain(doc *Document, selector string) (*Explanation, error) {
+	reg := DefaultSelectorRegistry.snapshot(doc)
+
+	ex := &Explanation{Selector: selector}
+	matched := make(map[*html.Node]bool)
+	for _, g := range splitSelectorGroups(selector) {
+		g = strings.TrimSpace(g)
+		if isRelativeSelector(g) {
+			return nil, errors.New("goquery: relative selector without a scope: " + g)
+		}
+		parts, combs, e := splitCompounds(g)
+		if e != nil {
+			return nil, e
+		}
+		if len(parts) == 0 {
+			return nil, errors.New("goquery: empty selector group in " + selector)
+		}
+
+		eg := ExplainGroup{Selector: g}
+		var current []*html.Node
+		for i, p := range parts {
+			m, e := reg.compileCompound(p)
+			if e != nil {
+				return nil, e
+			}
+			simple, e := reg.compileSimpleSelectors(p)
+			if e != nil {
+				return nil, e
+			}
+
+			step := ExplainStep{Compound: p}
+			var cands []*html.Node
+			if i == 0 {
+				cands = elementsIn(doc.rootNode, nil)
+			} else {
+				step.Combinator = string(combs[i])
+				cands = combinatorTargets(doc.rootNode, current, combs[i])
+			}
+			current = m.Filter(cands)
+			step.Candidates, step.Matches = len(cands), len(current)
+			step.NearMisses = nearMisses(doc, cands, current, simple)
+			eg.Steps = append(eg.Steps, step)
+		}
+		for _, n := range current {
+			matched[n] = true
+		}
+		ex.Groups = append(ex.Groups, eg)
+	}
+
+	ex.Matches = len(matched)
+	return ex, nil
+}
+
+// String returns the text report of the explanation.
+func (ex *Explanation) String() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "selector %q: %s\n", ex.Selector, pluralize(ex.Matches, "match", "matches"))
+	for _, g := range ex.Groups {
+		if len(ex.Groups) > 1 {
+			fmt.Fprintf(&buf, "group %q\n", g.Selector)
+		}
+		for i, st := range g.Steps {
+			comb := st.Combinator
+			if comb == " " {
+				comb = "(descendant)"
+			}
+			if comb != "" {
+				comb += " "
+			}
+			fmt.Fprintf(&buf, "  %d. %s%s: %s, %s", i+1, comb, st.Compound,
+				pluralize(st.Candidates, "candidate", "candidates"), pluralize(st.Matches, "match", "matches"))
+			if st.Matches == 0 && st.Candidates > 0 {
+				buf.WriteString(" <- stops matching")
+			}
+			buf.WriteByte('\n')
+			for _, nm := range st.NearMisses {
+				fmt.Fprintf(&buf, "     near miss %s: matches %s, fails %s\n", nm.Path,
+					strings.Join(nm.Matched, ""), strings.Join(nm.Failed, ""))
+			}
+		}
+	}
+	return buf.String()
+}
+
+func pluralize(n int, one, many string) string {
+	if n == 1 {
+		return "1 " + one
+	}
+	return fmt.Sprintf("%d %s", n, many)
+}
+
+// A simple selector of a compound selector, and its Matcher.
+type simpleSelector struct {
+	sel string
+	m   Matcher
+}
+
+// Compiles each simple selector of the compound selector.
+func (reg *SelectorRegistry) compileSimpleSelectors(p string) ([]simpleSelector, error) {
+	var simple []simpleSelector
+	for _, s := range splitSimpleSelectors(p) {
+		m, e := reg.compileCompound(s)
+		if e != nil {
+			return nil, e
+		}
+		simple = append(simple, simpleSelector{s, m})
+	}
+	return simple, nil
+}
+
+// Splits the compound selector in its simple selectors: the type selector,
+// and the id, class, attribute and pseudo-class selectors.
+func splitSimpleSelectors(p string) (parts []string) {
+	depth := 0
+	var quote byte
+	start := 0
+	split := func(i int) {
+		if i > start {
+			parts = append(parts, p[start:i])
+			start = i
+		}
+	}
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		switch {
+		case c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '(':
+			if c == '[' && depth == 0 {
+				split(i)
+			}
+			depth++
+		case c == ']' || c == ')':
+			depth--
+		case depth == 0 && (c == '.' || c == '#' || (c == ':' && (i == 0 || p[i-1] != ':'))):
+			split(i)
+		}
+	}
+	split(len(p))
+	return
+}
+
+// Returns the candidates that are not matches but match at least one of the
+// simple selectors, starting with the ones failing the fewest.
+func nearMisses(doc *Document, cands, matches []*html.Node, simple []simpleSelector) []NearMiss {
+	if len(simple) < 2 {
+		return nil
+	}
+	matched := make(map[*html.Node]struct{}, len(matches))
+	for _, n := range matches {
+		matched[n] = struct{}{}
+	}
+	var misses []NearMiss
+	for _, n := range cands {
+		if _, ok := matched[n]; ok {
+			continue
+		}
+		var nm NearMiss
+		for _, s := range simple {
+			if s.m.Match(n) {
+				nm.Matched = append(nm.Matched, s.sel)
+			} else {
+				nm.Failed = append(nm.Failed, s.sel)
+			}
+		}
+		if len(nm.Matched) > 0 {
+			nm.Node = n
+			misses = append(misses, nm)
+		}
+	}
+
+	sort.SliceStable(misses, func(i, j int) bool {
+		return len(misses[i].Failed) < len(misses[j].Failed)
+	})
+	if len(misses) > maxNearMisses {
+		misses = misses[:maxNearMisses]
+	}
+	for i := range misses {
+		misses[i].Path = newSingleSelection(misses[i].Node, doc).Path()
+	}
+	return misses
+}
+
+// Returns the elements of the tree of root, in document order, that are in
+// the set, or all of them if the set is nil.
+func elementsIn(root *html.Node, set map[*html.Node]bool) (ns []*html.Node) {
+	for n := root; n != nil; n = nextInTree(n, root) {
+		if n.Type == html.ElementNode && (set == nil || set[n]) {
+			ns = append(ns, n)
+		}
+	}
+	return
+}
+
+// Returns the elements related to the nodes through the combinator, in
+// document order.
+func combinatorTargets(root *html.Node, nodes []*html.Node, comb byte) []*html.Node {
+	set := make(map[*html.Node]bool)
+	var from map[*html.Node]bool
+	if comb == ' ' {
+		from = make(map[*html.Node]bool, len(nodes))
+		for _, n := range nodes {
+			from[n] = true
+		}
+	}
+	for _, n := range nodes {
+		switch comb {
+		case ' ':
+			if hasAncestorIn(n, from) {
+				// Its descendants are those of the ancestor
+				continue
+			}
+			for c := nextInTree(n, n); c != nil; c = nextInTree(c, n) {
+				set[c] = true
+			}
+		case '>':
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				set[c] = true
+			}
+		case '+', '~':
+			for c := n.NextSibling; c != nil; c = c.NextSibling {
+				if c.Type == html.ElementNode {
+					set[c] = true
+					if comb == '+' {
+						break
+					}
+				}
+			}
+		}
+	}
+	return elementsIn(root, set)
+}
+
+// Checks if an ancestor of the node is in the set.
+func hasAncestorIn(n *html.Node, set map[*html.Node]bool) bool {
+	for p := n.Parent; p != nil; p = p.Parent {
+		if set[p] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package goquery
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+const explainHtml = `<div class="content"><ul><li class="item">a</li><li>b</li></ul></div><div class="sidebar"><ul><li class="item">c</li></ul></div>`
+
+func TestExplain(t *testing.T) {
+	doc := loadString(t, explainHtml)
+
+	ex, e := Explain(doc, "div.content > ul li.item.new")
+	if e != nil {
+		t.Fatal(e)
+	}
+	if ex.Matches != 0 || len(ex.Groups) != 1 {
+		t.Fatalf("unexpected explanation %+v", ex)
+	}
+	steps := ex.Groups[0].Steps
+	if len(steps) != 3 {
+		t.Fatalf("expected 3 steps, got %d", len(steps))
+	}
+	if st := steps[0]; st.Combinator != "" || st.Compound != "div.content" || st.Candidates != 10 || st.Matches != 1 {
+		t.Errorf("unexpected first step %+v", st)
+	}
+	if nm := steps[0].NearMisses; len(nm) != 1 || nm[0].Path != "html > body > div:nth-child(2)" ||
+		strings.Join(nm[0].Failed, "") != ".content" {
+		t.Errorf("unexpected near misses %+v", nm)
+	}
+	if st := steps[1]; st.Combinator != ">" || st.Candidates != 1 || st.Matches != 1 {
+		t.Errorf("unexpected second step %+v", st)
+	}
+	st := steps[2]
+	if st.Combinator != " " || st.Candidates != 2 || st.Matches != 0 || len(st.NearMisses) != 2 {
+		t.Fatalf("unexpected third step %+v", st)
+	}
+	if nm := st.NearMisses[0]; strings.Join(nm.Matched, "") != "li.item" || strings.Join(nm.Failed, "") != ".new" {
+		t.Errorf("expected the closest near miss first, got %+v", nm)
+	}
+
+	report := ex.String()
+	for _, s := range []string{`selector "div.content > ul li.item.new": 0 matches`,
+		"3. (descendant) li.item.new: 2 candidates, 0 matches <- stops matching",
+		"near miss html > body > div:nth-child(1) > ul > li:nth-child(1): matches li.item, fails .new"} {
+		if !strings.Contains(report, s) {
+			t.Errorf("expected %q in the report:\n%s", s, report)
+		}
+	}
+}
+
+func TestExplainNestedDescendants(t *testing.T) {
+	doc := loadString(t, `<div><div><div><p>a</p></div></div><p>b</p></div><p>c</p>`)
+
+	ex, e := Explain(doc, "div p")
+	if e != nil {
+		t.Fatal(e)
+	}
+	// The descendants of the nested divs are reached once
+	if st := ex.Groups[0].Steps[1]; st.Candidates != 4 || st.Matches != 2 {
+		t.Errorf("unexpected second step %+v", st)
+	}
+	if ex.Matches != 2 {
+		t.Errorf("expected 2 matches, got %d", ex.Matches)
+	}
+}
+
+func TestExplainGroups(t *testing.T) {
+	doc := loadString(t, explainHtml)
+
+	ex, e := Explain(doc, "li.item, .sidebar ~ div, div + div li")
+	if e != nil {
+		t.Fatal(e)
+	}
+	if ex.Matches != 2 || len(ex.Groups) != 3 {
+		t.Fatalf("unexpected explanation %+v", ex)
+	}
+	if st := ex.Groups[1].Steps[1]; st.Candidates != 0 || st.Matches != 0 {
+		t.Errorf("unexpected sibling step %+v", st)
+	}
+	if st := ex.Groups[2].Steps[1]; st.Candidates != 1 || st.Matches != 1 {
+		t.Errorf("unexpected adjacent sibling step %+v", st)
+	}
+	if n := doc.Find("li.item, .sidebar ~ div, div + div li").Length(); n != ex.Matches {
+		t.Errorf("expected %d matches as Find, got %d", n, ex.Matches)
+	}
+
+	for _, sel := range []string{"> li", "li:unknown", "li >", "a,"} {
+		if _, e := Explain(doc, sel); e == nil {
+			t.Errorf("%s: expected an error", sel)
+		}
+	}
+}
+
+func TestExplainPseudoUsingRegistry(t *testing.T) {
+	doc := loadString(t, explainHtml)
+	// The pseudo-class changes the registry, which must not be locked
+	// while it is called
+	DefaultSelectorRegistry.Register("registering", func(n *html.Node, arg string) bool {
+		DefaultSelectorRegistry.Register("registered", langIs)
+		return true
+	})
+	defer DefaultSelectorRegistry.Unregister("registering")
+	defer DefaultSelectorRegistry.Unregister("registered")
+
+	ex, e := Explain(doc, "li.item:registering")
+	if e != nil {
+		t.Fatal(e)
+	}
+	if ex.Matches != 2 {
+		t.Errorf("expected 2 matches, got %d", ex.Matches)
+	}
+}
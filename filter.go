@@ -1,14 +1,13 @@
 package goquery
 
 import (
-	"code.google.com/p/cascadia"
 	"golang.org/x/net/html"
 )
 
 // Filter reduces the set of matched elements to those that match the selector string.
 // It returns a new Selection object for this subset of matching elements.
 func (s *Selection) Filter(selector string) *Selection {
-	return s.FilterMatcher(cascadia.MustCompile(selector))
+	return s.FilterMatcher(compileMatcher(selector, s))
 }
 
 // FilterMatcher reduces the set of matched elements to those that match
@@ -21,7 +20,7 @@ func (s *Selection) FilterMatcher(m Matcher) *Selection {
 // Not removes elements from the Selection that match the selector string.
 // It returns a new Selection object with the matching elements removed.
 func (s *Selection) Not(selector string) *Selection {
-	return s.NotMatcher(cascadia.MustCompile(selector))
+	return s.NotMatcher(compileMatcher(selector, s))
 }
 
 // NotMatcher removes elements from the Selection that match the given matcher.
@@ -82,7 +81,7 @@ func (s *Selection) Intersection(sel *Selection) *Selection {
 // that matches the selector.
 // It returns a new Selection object with the matching elements.
 func (s *Selection) Has(selector string) *Selection {
-	return s.HasSelection(s.document.Find(selector))
+	return s.HasMatcher(compileMatcher(selector, s))
 }
 
 // HasMatcher reduces the set of matched elements to those that have a descendant
@@ -0,0 +1,243 @@
+package goquery
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"code.google.com/p/cascadia"
+	"golang.org/x/net/html"
+)
+
+// InducedSelector is a selector proposed by InduceSelectors.
+type InducedSelector struct {
+	// Selector is the CSS selector, and Matcher its compiled version, to be
+	// used with FindMatcher and the other ...Matcher methods.
+	Selector string
+	Matcher  Matcher
+	// Precision is the fraction of the examples matched by the selector that
+	// are positive examples. All the positive examples are matched.
+	Precision float64
+	// Stability estimates how robust the selector is to changes of the
+	// document, from 0 to 1: tag names and class names are preferred over
+	// attribute values, which are preferred over positions.
+	Stability float64
+	// Matches is the number of nodes matched in the document.
+	Matches int
+}
+
+// InduceSelectors proposes CSS selectors matching all the positive examples
+// of the document, generalized from the features they have in common: the
+// tag name, the class names and the attribute values of the examples, their
+// position among their siblings, and the same features of their parent and
+// ancestors. The id and class names looking generated (see
+// UniqueSelectorOptions.IsStable) are not used. The negatives are optional
+// counter-examples, they may be nil.
+//
+// The selectors are sorted by decreasing precision on the examples, then by
+// increasing number of matches, as the nodes matched besides the positive
+// examples are likely unwanted, then by decreasing stability, then by
+// increasing length. It returns nil if there is no positive example or if no
+// selector matches them all.
+func InduceSelectors(doc *Document, positives, negatives *Selection) []InducedSelector {
+	var pos []*html.Node
+	for _, n := range positives.Nodes {
+		if n.Type == html.ElementNode {
+			pos = append(pos, n)
+		}
+	}
+	if len(pos) == 0 {
+		return nil
+	}
+	var neg []*html.Node
+	if negatives != nil {
+		neg = negatives.Nodes
+	}
+
+	var res []InducedSelector
+	seen := make(map[string]bool)
+	for _, c := range induceCandidates(pos) {
+		if seen[c.sel] {
+			continue
+		}
+		seen[c.sel] = true
+
+		m, e := cascadia.Compile(c.sel)
+		if e != nil {
+			continue
+		}
+		matched := m.MatchAll(doc.rootNode)
+		if !containsAll(matched, pos) {
+			continue
+		}
+		negMatched := 0
+		for _, n := range neg {
+			if isInSlice(matched, n) {
+				negMatched++
+			}
+		}
+		res = append(res, InducedSelector{
+			Selector:  c.sel,
+			Matcher:   m,
+			Precision: float64(len(pos)) / float64(len(pos)+negMatched),
+			Stability: c.stability,
+			Matches:   len(matched),
+		})
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		a, b := res[i], res[j]
+		switch {
+		case a.Precision != b.Precision:
+			return a.Precision > b.Precision
+		case a.Matches != b.Matches:
+			return a.Matches < b.Matches
+		case a.Stability != b.Stability:
+			return a.Stability > b.Stability
+		case len(a.Selector) != len(b.Selector):
+			return len(a.Selector) < len(b.Selector)
+		}
+		return a.Selector < b.Selector
+	})
+	return res
+}
+
+// A candidate selector and its stability.
+type induceCandidate struct {
+	sel       string
+	stability float64
+}
+
+// Stability of the parts of the selectors.
+const (
+	induceTagStability   = 1
+	induceClassStability = 0.9
+	induceIdStability    = 0.85
+	induceAttrStability  = 0.8
+	induceNthStability   = 0.5
+)
+
+// Returns the candidate selectors generalizing the examples.
+func induceCandidates(pos []*html.Node) []induceCandidate {
+	// The selectors of the examples themselves
+	nodes := commonCandidates(pos, func(n *html.Node) []induceCandidate {
+		return compoundCandidates(n, true)
+	})
+	if len(nodes) == 0 {
+		return nil
+	}
+
+	// The selectors of their parents and ancestors
+	parents := commonCandidates(pos, func(n *html.Node) []induceCandidate {
+		if n.Parent == nil || n.Parent.Type != html.ElementNode {
+			return nil
+		}
+		return compoundCandidates(n.Parent, false)
+	})
+	ancestors := commonCandidates(pos, func(n *html.Node) []induceCandidate {
+		var cands []induceCandidate
+		for p := n.Parent; p != nil && p.Type == html.ElementNode; p = p.Parent {
+			if p.Data == "html" || p.Data == "body" {
+				break
+			}
+			for _, c := range compoundCandidates(p, false) {
+				// The tag name alone is too vague for an ancestor
+				if c.sel != p.Data {
+					cands = append(cands, c)
+				}
+			}
+		}
+		return cands
+	})
+
+	cands := append([]induceCandidate(nil), nodes...)
+	for _, c := range nodes {
+		for _, p := range parents {
+			cands = append(cands, induceCandidate{p.sel + " > " + c.sel, c.stability * p.stability})
+		}
+		for _, a := range ancestors {
+			cands = append(cands, induceCandidate{a.sel + " " + c.sel, c.stability * a.stability})
+		}
+	}
+	return cands
+}
+
+// Returns the candidates that f returns for all the nodes, in the order of
+// the first node.
+func commonCandidates(ns []*html.Node, f func(*html.Node) []induceCandidate) []induceCandidate {
+	common := f(ns[0])
+	for _, n := range ns[1:] {
+		set := make(map[string]bool)
+		for _, c := range f(n) {
+			set[c.sel] = true
+		}
+		kept := common[:0]
+		for _, c := range common {
+			if set[c.sel] {
+				kept = append(kept, c)
+			}
+		}
+		common = kept
+	}
+	return common
+}
+
+// Returns the compound selectors matching the element: its tag name, alone
+// and with each stable class name, with each stable attribute value and with
+// its position if nth is true, and its stable id.
+func compoundCandidates(n *html.Node, nth bool) []induceCandidate {
+	tag := cssEscape(n.Data)
+	cands := []induceCandidate{{tag, induceTagStability}}
+
+	if id, ok := getAttributeValue("id", n); ok && id != "" && isStableName(id) {
+		cands = append(cands, induceCandidate{"#" + cssEscape(id), induceIdStability})
+	}
+
+	classes, _ := getClassesAndAttr(n, false)
+	for _, cl := range classes {
+		if isStableName(cl) {
+			cl = "." + cssEscape(cl)
+			cands = append(cands,
+				induceCandidate{tag + cl, induceClassStability},
+				induceCandidate{cl, induceClassStability * 0.95})
+		}
+	}
+
+	for _, a := range n.Attr {
+		if a.Namespace != "" || !isInducedAttr(a) {
+			continue
+		}
+		cands = append(cands, induceCandidate{
+			tag + "[" + cssEscape(a.Key) + "=" + cssQuote(a.Val) + "]", induceAttrStability})
+	}
+
+	if nth {
+		cands = append(cands, induceCandidate{
+			tag + ":nth-child(" + strconv.Itoa(elementIndex(n)) + ")", induceNthStability})
+	}
+	return cands
+}
+
+// Checks if the attribute can be used by the induced selectors: its value
+// must be short and stable, and it must not be an id, class, style, link or
+// event handler attribute.
+func isInducedAttr(a html.Attribute) bool {
+	switch a.Key {
+	case "id", "class", "style", "href", "src", "srcset", "action", "value":
+		return false
+	}
+	if strings.HasPrefix(a.Key, "on") || a.Val == "" || len(a.Val) > 40 {
+		return false
+	}
+	return isStableName(a.Val)
+}
+
+// Checks if all the nodes are in the slice.
+func containsAll(slc []*html.Node, ns []*html.Node) bool {
+	for _, n := range ns {
+		if !isInSlice(slc, n) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,82 @@
+package goquery
+
+import (
+	"strings"
+	"testing"
+)
+
+const productsHtml = `<div id="results">
+<div class="product css-9f8e7d"><h2 class="name">A</h2><span class="price">1</span></div>
+<div class="product css-1a2b3c"><h2 class="name">B</h2><span class="price">2</span></div>
+<div class="product"><h2 class="name">C</h2><span class="price">3</span></div>
+</div>
+<div id="ads"><div class="product"><h2 class="name">Ad</h2></div></div>`
+
+func TestInduceSelectors(t *testing.T) {
+	doc := loadString(t, productsHtml)
+	names := doc.Find("#results h2")
+
+	res := InduceSelectors(doc, names.Slice(0, 2), nil)
+	if len(res) == 0 {
+		t.Fatal("expected selectors")
+	}
+	// The tightest selector wins over the bare tag name
+	if res[0].Selector != "#results h2" || res[0].Precision != 1 || res[0].Matches != 3 {
+		t.Errorf("unexpected best selector %+v", res[0])
+	}
+	for _, r := range res {
+		if doc.FindMatcher(r.Matcher).FilterSelection(names.Slice(0, 2)).Length() != 2 {
+			t.Errorf("%s: expected the examples to be matched", r.Selector)
+		}
+		if len(doc.FindMatcher(r.Matcher).Nodes) != r.Matches {
+			t.Errorf("%s: expected %d matches", r.Selector, r.Matches)
+		}
+		if containsGenerated(r.Selector) {
+			t.Errorf("%s: expected no generated class", r.Selector)
+		}
+	}
+}
+
+func TestInduceSelectorsTightest(t *testing.T) {
+	doc := loadString(t, `<h2 class="title">a</h2><p>x</p><h2 class="title">b</h2><h2>other</h2>`)
+
+	res := InduceSelectors(doc, doc.Find("h2.title"), nil)
+	if len(res) == 0 {
+		t.Fatal("expected selectors")
+	}
+	if res[0].Selector != "h2.title" || res[0].Matches != 2 {
+		t.Errorf("unexpected best selector %+v", res[0])
+	}
+	for _, r := range res[1:] {
+		if r.Selector == "h2" && r.Matches != 3 {
+			t.Errorf("expected h2 to match 3 nodes, got %d", r.Matches)
+		}
+	}
+}
+
+func containsGenerated(sel string) bool {
+	return strings.Contains(sel, "css-")
+}
+
+func TestInduceSelectorsNegatives(t *testing.T) {
+	doc := loadString(t, productsHtml)
+
+	res := InduceSelectors(doc, doc.Find("#results h2").Slice(0, 2), doc.Find("#ads h2"))
+	best := res[0]
+	if best.Precision != 1 || best.Selector != "#results h2" {
+		t.Errorf("unexpected best selector %+v", best)
+	}
+	if sel := doc.FindMatcher(best.Matcher); !sel.IsSelection(doc.Find("#results h2")) {
+		t.Errorf("expected all the names to be matched, got %d nodes", sel.Length())
+	}
+	if last := res[len(res)-1]; last.Precision != 2.0/3 {
+		t.Errorf("expected a selector matching the negative example last, got %+v", last)
+	}
+}
+
+func TestInduceSelectorsNone(t *testing.T) {
+	doc := loadString(t, productsHtml)
+	if res := InduceSelectors(doc, doc.Find("none"), nil); res != nil {
+		t.Errorf("expected no selector, got %v", res)
+	}
+}
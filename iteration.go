@@ -1,5 +1,12 @@
 package goquery
 
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
 // Each iterates over a Selection object, executing a function for each
 // matched element. It returns the current Selection object.
 func (s *Selection) Each(f func(int, *Selection)) *Selection {
@@ -31,3 +38,120 @@ func (s *Selection) Map(f func(int, *Selection) string) (result []string) {
 
 	return result
 }
+
+// EachParallel iterates over a Selection object, executing a function for
+// each matched element on up to workers goroutines at once. If workers is
+// less than 1, runtime.GOMAXPROCS(0) is used. The index passed to the function
+// is the element's position in the Selection, as with Each.
+//
+// It returns the first error returned by the function, or the context's error
+// if the context is done before all elements were processed. Once an error
+// occurs or the context is done, no new element is dispatched, but calls
+// already in flight run to completion before EachParallel returns.
+//
+// The function is called concurrently, so it must only use methods that do
+// not modify the document. Those are the methods from array.go, expand.go,
+// filter.go, query.go and traversal.go, plus the getters from property.go
+// (Attr, HasClass, Html, Length, Size and Text) and ComputedStyle. The
+// manipulation methods, SetAttr, RemoveAttr and the *Class setters modify
+// the shared nodes and must not be called concurrently on the same Document.
+func (s *Selection) EachParallel(ctx context.Context, workers int, f func(int, *Selection) error) error {
+	return eachParallel(ctx, s, workers, f)
+}
+
+// MapParallel passes each element in the current matched set through a
+// function, like Map, but runs the function on up to workers goroutines at
+// once. The returned slice holds the values in the same order as the
+// elements in the Selection. It returns a nil slice along with the first
+// error returned by the function or the context's error.
+//
+// This follows the same rules as Selection.EachParallel.
+func (s *Selection) MapParallel(ctx context.Context, workers int, f func(int, *Selection) (string, error)) ([]string, error) {
+	result := make([]string, len(s.Nodes))
+	e := eachParallel(ctx, s, workers, func(i int, sel *Selection) error {
+		val, err := f(i, sel)
+		if err != nil {
+			return err
+		}
+		// Each index is written by a single goroutine, no locking required
+		result[i] = val
+		return nil
+	})
+	if e != nil {
+		return nil, e
+	}
+	return result, nil
+}
+
+// Internal implementation of EachParallel, shared with MapParallel.
+func eachParallel(ctx context.Context, sel *Selection, workers int, f func(int, *Selection) error) error {
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(sel.Nodes) {
+		workers = len(sel.Nodes)
+	}
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+		skipped  int32
+	)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	fail := func(e error) {
+		once.Do(func() {
+			firstErr = e
+			cancel()
+		})
+	}
+
+	indices := make(chan int)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				// The dispatch may race with the cancellation, skip the
+				// remaining indices once the context is done.
+				if ctx.Err() != nil {
+					atomic.StoreInt32(&skipped, 1)
+					continue
+				}
+				if e := f(i, newSingleSelection(sel.Nodes[i], sel.document)); e != nil {
+					fail(e)
+				}
+			}
+		}()
+	}
+
+	// Dispatch the indices until all are sent or the context is done, either
+	// by the caller or because a call failed.
+	var ctxErr error
+dispatch:
+	for i := range sel.Nodes {
+		// Check first so that a done context is never raced by a ready worker
+		if ctxErr = ctx.Err(); ctxErr != nil {
+			break
+		}
+		select {
+		case indices <- i:
+		case <-ctx.Done():
+			ctxErr = ctx.Err()
+			break dispatch
+		}
+	}
+	close(indices)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	if ctxErr == nil && skipped != 0 {
+		// The derived context is only cancelled by fail, so the parent one
+		// is done.
+		ctxErr = ctx.Err()
+	}
+	return ctxErr
+}
@@ -0,0 +1,75 @@
+//go:build go1.18
+// +build go1.18
+
+package goquery
+
+import (
+	"golang.org/x/net/html"
+)
+
+// MapTo passes each element in the Selection through a function, producing a
+// slice holding the returned values. It is the generic counterpart of
+// Selection.Map, which can only produce strings.
+func MapTo[T any](s *Selection, f func(int, *Selection) T) []T {
+	result := make([]T, 0, len(s.Nodes))
+	for i, n := range s.Nodes {
+		result = append(result, f(i, newSingleSelection(n, s.document)))
+	}
+	return result
+}
+
+// MapErr passes each element in the Selection through a function, producing
+// a slice holding the returned values. It stops at the first error returned
+// by the function, and returns a nil slice along with that error.
+func MapErr[T any](s *Selection, f func(int, *Selection) (T, error)) ([]T, error) {
+	result := make([]T, 0, len(s.Nodes))
+	for i, n := range s.Nodes {
+		v, e := f(i, newSingleSelection(n, s.document))
+		if e != nil {
+			return nil, e
+		}
+		result = append(result, v)
+	}
+	return result, nil
+}
+
+// Reduce passes each element in the Selection through a function, along with
+// the value accumulated so far, starting with init. It returns the value
+// returned by the last call, or init if the Selection is empty.
+func Reduce[T any](s *Selection, init T, f func(T, int, *Selection) T) T {
+	acc := init
+	for i, n := range s.Nodes {
+		acc = f(acc, i, newSingleSelection(n, s.document))
+	}
+	return acc
+}
+
+// GroupBy splits the elements of the Selection based on the key returned by
+// the function for each of them. Each group is a new Selection object, with
+// the elements in the same order as in the source Selection, which is kept
+// on the stack so End works as usual.
+func GroupBy[K comparable](s *Selection, f func(int, *Selection) K) map[K]*Selection {
+	groups := make(map[K][]*html.Node)
+	for i, n := range s.Nodes {
+		k := f(i, newSingleSelection(n, s.document))
+		groups[k] = append(groups[k], n)
+	}
+
+	result := make(map[K]*Selection, len(groups))
+	for k, nodes := range groups {
+		result[k] = pushStack(s, nodes)
+	}
+	return result
+}
+
+// ToMap passes each element in the Selection through a function returning a
+// key and a value, and collects them in a map. If the same key is returned
+// more than once, the value of the last element wins.
+func ToMap[K comparable, V any](s *Selection, f func(int, *Selection) (K, V)) map[K]V {
+	result := make(map[K]V, len(s.Nodes))
+	for i, n := range s.Nodes {
+		k, v := f(i, newSingleSelection(n, s.document))
+		result[k] = v
+	}
+	return result
+}
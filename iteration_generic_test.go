@@ -0,0 +1,118 @@
+//go:build go1.18
+// +build go1.18
+
+package goquery
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMapTo(t *testing.T) {
+	sel := Doc().Find(".pvk-content")
+	vals := MapTo(sel, func(i int, s *Selection) int {
+		return i * 2
+	})
+	if len(vals) != 3 {
+		t.Fatalf("Expected MapTo result to have a length of 3, found %v.", len(vals))
+	}
+	for i, v := range vals {
+		if v != i*2 {
+			t.Errorf("Expected value %d to be %d, found %d.", i, i*2, v)
+		}
+	}
+}
+
+func TestMapToEmptySelection(t *testing.T) {
+	vals := MapTo(Doc().Find("zzzz"), func(i int, s *Selection) bool {
+		return true
+	})
+	if len(vals) != 0 {
+		t.Errorf("Expected MapTo result to be empty, found %v.", vals)
+	}
+}
+
+func TestMapErr(t *testing.T) {
+	sel := Doc().Find(".pvk-content")
+	vals, e := MapErr(sel, func(i int, s *Selection) (*Selection, error) {
+		return s, nil
+	})
+	if e != nil {
+		t.Fatalf("Expected no error, got %s.", e)
+	}
+	if len(vals) != 3 {
+		t.Fatalf("Expected MapErr result to have a length of 3, found %v.", len(vals))
+	}
+	for i, v := range vals {
+		if v.Get(0) != sel.Get(i) {
+			t.Errorf("Expected value %d to be %+v, found %+v.", i, sel.Get(i), v.Get(0))
+		}
+	}
+}
+
+func TestMapErrError(t *testing.T) {
+	errStop := errors.New("stop")
+
+	var cnt int
+	vals, e := MapErr(Doc().Find(".pvk-content"), func(i int, s *Selection) (int, error) {
+		cnt++
+		if i == 1 {
+			return 0, errStop
+		}
+		return i, nil
+	})
+	if e != errStop {
+		t.Errorf("Expected error %v, got %v.", errStop, e)
+	}
+	if vals != nil {
+		t.Errorf("Expected nil result on error, got %v.", vals)
+	}
+	if cnt != 2 {
+		t.Errorf("Expected MapErr to stop after 2 calls, got %d.", cnt)
+	}
+}
+
+func TestReduce(t *testing.T) {
+	sel := Doc().Find("div")
+	cnt := Reduce(sel, 0, func(acc int, i int, s *Selection) int {
+		return acc + s.Length()
+	})
+	if cnt != sel.Length() {
+		t.Errorf("Expected Reduce to return %d, got %d.", sel.Length(), cnt)
+	}
+
+	init := Reduce(Doc().Find("zzzz"), "init", func(acc string, i int, s *Selection) string {
+		return "called"
+	})
+	if init != "init" {
+		t.Errorf("Expected Reduce on empty Selection to return init value, got %q.", init)
+	}
+}
+
+func TestGroupBy(t *testing.T) {
+	sel := Doc().Find(".pvk-content, .hero-unit")
+	groups := GroupBy(sel, func(i int, s *Selection) bool {
+		return s.HasClass("pvk-content")
+	})
+	if len(groups) != 2 {
+		t.Fatalf("Expected 2 groups, found %d.", len(groups))
+	}
+	assertLength(t, groups[true].Nodes, 3)
+	assertLength(t, groups[false].Nodes, sel.Length()-3)
+	assertEqual(t, groups[true].End(), sel)
+}
+
+func TestToMap(t *testing.T) {
+	m := ToMap(Doc().Find("[id]"), func(i int, s *Selection) (string, *Selection) {
+		id, _ := s.Attr("id")
+		return id, s
+	})
+	if len(m) == 0 {
+		t.Fatal("Expected ToMap result to have entries.")
+	}
+	for id, s := range m {
+		if !s.Is("#" + id) {
+			t.Errorf("Expected entry %q to be the node with that id, found %+v.", id, s.Get(0))
+		}
+	}
+}
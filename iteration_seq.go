@@ -0,0 +1,142 @@
+//go:build go1.23
+// +build go1.23
+
+package goquery
+
+import (
+	"iter"
+
+	"golang.org/x/net/html"
+)
+
+// All returns an iterator over the elements of the Selection, yielding the
+// index and a Selection object of the element, like Each. It can be used in
+// a for-range loop, and breaking out of the loop stops the iteration.
+func (s *Selection) All() iter.Seq2[int, *Selection] {
+	return func(yield func(int, *Selection) bool) {
+		for i, n := range s.Nodes {
+			if !yield(i, newSingleSelection(n, s.document)) {
+				return
+			}
+		}
+	}
+}
+
+// NodesSeq returns an iterator over the nodes of the Selection.
+func (s *Selection) NodesSeq() iter.Seq[*html.Node] {
+	return func(yield func(*html.Node) bool) {
+		for _, n := range s.Nodes {
+			if !yield(n) {
+				return
+			}
+		}
+	}
+}
+
+// Descendants returns an iterator over the descendant elements of each
+// element in the Selection, in document order for each of them. Unlike
+// Find("*"), the tree is walked lazily, so breaking out of the loop stops
+// the walk and no intermediate slice is allocated. A node is yielded only
+// once even if more than one element of the Selection contains it.
+func (s *Selection) Descendants() iter.Seq[*html.Node] {
+	return func(yield func(*html.Node) bool) {
+		for i, root := range s.Nodes {
+			if isCoveredBy(s.Nodes, i) {
+				continue
+			}
+			for n := nextInTree(root, root); n != nil; n = nextInTree(n, root) {
+				if n.Type == html.ElementNode && !yield(n) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Ancestors returns an iterator over the ancestor elements of each element
+// in the Selection, closest first, like Parents. The tree is walked lazily,
+// so breaking out of the loop stops the walk and no intermediate slice is
+// allocated. A node is yielded only once even if it is an ancestor of more
+// than one element of the Selection.
+func (s *Selection) Ancestors() iter.Seq[*html.Node] {
+	return func(yield func(*html.Node) bool) {
+		for i, n := range s.Nodes {
+			for p := n.Parent; p != nil; p = p.Parent {
+				// If p contains a previous element, then p and all its
+				// ancestors have already been yielded.
+				if containsAny(p, s.Nodes[:i]) {
+					break
+				}
+				if p.Type == html.ElementNode && !yield(p) {
+					return
+				}
+				// If p is a previous element, its ancestors have already
+				// been yielded, but not p itself.
+				if isInSlice(s.Nodes[:i], p) {
+					break
+				}
+			}
+		}
+	}
+}
+
+// FollowingSiblings returns an iterator over the following sibling elements
+// of each element in the Selection, like NextAll. The siblings are walked
+// lazily, so breaking out of the loop stops the walk and no intermediate
+// slice is allocated. A node is yielded only once even if it follows more
+// than one element of the Selection.
+func (s *Selection) FollowingSiblings() iter.Seq[*html.Node] {
+	return func(yield func(*html.Node) bool) {
+		for i, n := range s.Nodes {
+			// If a previous element precedes this one, its following
+			// siblings have already been yielded.
+			if isInSlice(s.Nodes[:i], n) || precededByAny(n, s.Nodes[:i]) {
+				continue
+			}
+			for c := n.NextSibling; c != nil; c = c.NextSibling {
+				if c.Type != html.ElementNode {
+					continue
+				}
+				if !yield(c) {
+					return
+				}
+				// A previous element's following siblings have already been
+				// yielded.
+				if isInSlice(s.Nodes[:i], c) {
+					break
+				}
+			}
+		}
+	}
+}
+
+// Checks if the node at index i in nodes is a duplicate of a previous node,
+// or is within any of the other nodes.
+func isCoveredBy(nodes []*html.Node, i int) bool {
+	for j, n := range nodes {
+		if (j < i && n == nodes[i]) || nodeContains(n, nodes[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// Checks if the container node strictly contains any of the nodes.
+func containsAny(container *html.Node, nodes []*html.Node) bool {
+	for _, n := range nodes {
+		if nodeContains(container, n) {
+			return true
+		}
+	}
+	return false
+}
+
+// Checks if any of the nodes is a preceding sibling of n.
+func precededByAny(n *html.Node, nodes []*html.Node) bool {
+	for p := n.PrevSibling; p != nil; p = p.PrevSibling {
+		if isInSlice(nodes, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+//go:build go1.23
+// +build go1.23
+
+package goquery
+
+import (
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func collectNodes(seq func(func(*html.Node) bool)) (result []*html.Node) {
+	for n := range seq {
+		result = append(result, n)
+	}
+	return result
+}
+
+func assertSameNodes(t *testing.T, got, exp []*html.Node) {
+	assertLength(t, got, len(exp))
+	for _, n := range exp {
+		if !isInSlice(got, n) {
+			t.Errorf("Expected node %+v to be yielded.", n)
+		}
+	}
+}
+
+func TestAll(t *testing.T) {
+	sel := Doc().Find(".pvk-content")
+
+	var cnt int
+	for i, s := range sel.All() {
+		if s.Get(0) != sel.Get(i) {
+			t.Errorf("Expected node at index %d to be %+v, got %+v.", i, sel.Get(i), s.Get(0))
+		}
+		cnt++
+	}
+	if cnt != 3 {
+		t.Errorf("Expected All() to yield 3 times, got %d times.", cnt)
+	}
+}
+
+func TestAllWithBreak(t *testing.T) {
+	var cnt int
+	for range Doc().Find(".pvk-content").All() {
+		cnt++
+		break
+	}
+	if cnt != 1 {
+		t.Errorf("Expected All() to yield 1 time, got %d times.", cnt)
+	}
+}
+
+func TestNodesSeq(t *testing.T) {
+	sel := Doc().Find(".pvk-content")
+	nodes := collectNodes(sel.NodesSeq())
+	assertLength(t, nodes, 3)
+	for i, n := range nodes {
+		if n != sel.Get(i) {
+			t.Errorf("Expected node at index %d to be %+v, got %+v.", i, sel.Get(i), n)
+		}
+	}
+}
+
+func TestDescendants(t *testing.T) {
+	sel := Doc().Find(".hero-unit")
+	nodes := collectNodes(sel.Descendants())
+	assertSameNodes(t, nodes, sel.Find("*").Nodes)
+
+	// Document order
+	exp := sel.Find("*").Nodes
+	for i := range exp {
+		if i < len(nodes) && nodes[i] != exp[i] {
+			t.Errorf("Expected node at index %d to be %+v, got %+v.", i, exp[i], nodes[i])
+		}
+	}
+}
+
+func TestDescendantsNested(t *testing.T) {
+	sel := Doc().Find(".pvk-content .row-fluid").AddSelection(Doc().Find(".pvk-content"))
+	nodes := collectNodes(sel.Descendants())
+	assertSameNodes(t, nodes, sel.Find("*").Nodes)
+}
+
+func TestDescendantsWithBreak(t *testing.T) {
+	var cnt int
+	for n := range Doc().Descendants() {
+		cnt++
+		if n.Data == "head" {
+			break
+		}
+	}
+	if cnt != 2 {
+		t.Errorf("Expected Descendants() to stop at head after 2 nodes, got %d.", cnt)
+	}
+}
+
+func TestAncestors(t *testing.T) {
+	sel := Doc().Find(".span12, .footer")
+	nodes := collectNodes(sel.Ancestors())
+	assertSameNodes(t, nodes, sel.Parents().Nodes)
+}
+
+func TestAncestorsOfSelectedAncestor(t *testing.T) {
+	doc := loadString(t, `<div id="a"><p><span id="b"></span></p></div>`)
+	sel := doc.Find("#a, #b")
+	nodes := collectNodes(sel.Ancestors())
+	assertSameNodes(t, nodes, sel.Parents().Nodes)
+	if !isInSlice(nodes, doc.Find("#a").Get(0)) {
+		t.Error("Expected the selected ancestor to be yielded.")
+	}
+}
+
+func TestAncestorsWithBreak(t *testing.T) {
+	sel := Doc().Find(".footer")
+	for n := range sel.Ancestors() {
+		if n != sel.Get(0).Parent {
+			t.Errorf("Expected first ancestor to be the parent, got %+v.", n)
+		}
+		break
+	}
+}
+
+func TestFollowingSiblings(t *testing.T) {
+	sel := Doc().Find(".pvk-content").Last().AddSelection(Doc().Find(".pvk-content"))
+	nodes := collectNodes(sel.FollowingSiblings())
+	assertSameNodes(t, nodes, sel.NextAll().Nodes)
+}
+
+func TestFollowingSiblingsEmpty(t *testing.T) {
+	nodes := collectNodes(Doc().Find("zzzz").FollowingSiblings())
+	assertLength(t, nodes, 0)
+}
@@ -1,6 +1,9 @@
 package goquery
 
 import (
+	"context"
+	"errors"
+	"sync/atomic"
 	"testing"
 
 	"golang.org/x/net/html"
@@ -86,3 +89,123 @@ func TestForRange(t *testing.T) {
 		t.Errorf("expected initial selection to still have length %d, got %d", initLen, sel.Length())
 	}
 }
+
+func TestEachParallel(t *testing.T) {
+	var cnt int32
+
+	sel := Doc().Find("td, .hero-unit .row-fluid")
+	seen := make([]int32, sel.Length())
+	e := sel.EachParallel(context.Background(), 4, func(i int, s *Selection) error {
+		atomic.AddInt32(&cnt, 1)
+		atomic.AddInt32(&seen[i], 1)
+		if s.Get(0) != sel.Get(i) {
+			t.Errorf("Expected node at index %d to be %+v, got %+v.", i, sel.Get(i), s.Get(0))
+		}
+		return nil
+	})
+	if e != nil {
+		t.Fatalf("Expected no error, got %s.", e)
+	}
+	if int(cnt) != sel.Length() {
+		t.Errorf("Expected EachParallel() to call function %d times, got %d times.", sel.Length(), cnt)
+	}
+	for i, n := range seen {
+		if n != 1 {
+			t.Errorf("Expected index %d to be visited once, got %d.", i, n)
+		}
+	}
+}
+
+func TestEachParallelEmptySelection(t *testing.T) {
+	var cnt int32
+
+	e := Doc().Find("zzzz").EachParallel(context.Background(), 0, func(i int, s *Selection) error {
+		atomic.AddInt32(&cnt, 1)
+		return nil
+	})
+	if e != nil {
+		t.Errorf("Expected no error, got %s.", e)
+	}
+	if cnt > 0 {
+		t.Error("Expected EachParallel() to not be called on empty Selection.")
+	}
+}
+
+func TestEachParallelError(t *testing.T) {
+	errStop := errors.New("stop")
+
+	var cnt int32
+	sel := DocW().Find("td")
+	e := sel.EachParallel(context.Background(), 2, func(i int, s *Selection) error {
+		atomic.AddInt32(&cnt, 1)
+		if i == 3 {
+			return errStop
+		}
+		return nil
+	})
+	if e != errStop {
+		t.Errorf("Expected error %v, got %v.", errStop, e)
+	}
+	if int(cnt) == sel.Length() {
+		t.Errorf("Expected EachParallel() to stop dispatching after the error, got %d calls.", cnt)
+	}
+}
+
+func TestEachParallelCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var cnt int32
+	sel := DocW().Find("td")
+	e := sel.EachParallel(ctx, 1, func(i int, s *Selection) error {
+		if atomic.AddInt32(&cnt, 1) == 5 {
+			cancel()
+		}
+		return nil
+	})
+	if e != context.Canceled {
+		t.Errorf("Expected error %v, got %v.", context.Canceled, e)
+	}
+	if cnt != 5 {
+		t.Errorf("Expected EachParallel() to call function 5 times, got %d times.", cnt)
+	}
+}
+
+func TestMapParallel(t *testing.T) {
+	sel := Doc().Find(".pvk-content, .hero-unit .row-fluid")
+	exp := sel.Map(func(i int, s *Selection) string {
+		cls, _ := s.Attr("class")
+		return cls
+	})
+	vals, e := sel.MapParallel(context.Background(), 3, func(i int, s *Selection) (string, error) {
+		cls, _ := s.Attr("class")
+		return cls, nil
+	})
+	if e != nil {
+		t.Fatalf("Expected no error, got %s.", e)
+	}
+	if len(vals) != len(exp) {
+		t.Fatalf("Expected MapParallel array result to have a length of %d, found %d.", len(exp), len(vals))
+	}
+	for i := range exp {
+		if vals[i] != exp[i] {
+			t.Errorf("Expected value %d to be %q, found %q.", i, exp[i], vals[i])
+		}
+	}
+}
+
+func TestMapParallelError(t *testing.T) {
+	errStop := errors.New("stop")
+
+	vals, e := Doc().Find(".pvk-content").MapParallel(context.Background(), 0, func(i int, s *Selection) (string, error) {
+		if i == 1 {
+			return "", errStop
+		}
+		return "ok", nil
+	})
+	if e != errStop {
+		t.Errorf("Expected error %v, got %v.", errStop, e)
+	}
+	if vals != nil {
+		t.Errorf("Expected nil result on error, got %v.", vals)
+	}
+}
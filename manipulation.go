@@ -3,7 +3,6 @@ package goquery
 import (
 	"strings"
 
-	"code.google.com/p/cascadia"
 	"golang.org/x/net/html"
 )
 
@@ -15,7 +14,7 @@ import (
 //
 // This follows the same rules as Selection.Append.
 func (s *Selection) After(selector string) *Selection {
-	return s.AfterMatcher(cascadia.MustCompile(selector))
+	return s.AfterMatcher(compileMatcher(selector, s))
 }
 
 // AfterMatcher applies the matcher from the root document and inserts the matched elements
@@ -48,11 +47,14 @@ func (s *Selection) AfterHtml(html string) *Selection {
 //
 // This follows the same rules as Selection.Append.
 func (s *Selection) AfterNodes(ns ...*html.Node) *Selection {
-	return s.manipulateNodes(ns, true, func(sn *html.Node, n *html.Node) {
-		if sn.Parent != nil {
-			sn.Parent.InsertBefore(n, sn.NextSibling)
-		}
-	})
+	return s.manipulateNodes(ns, true, false, insertAfter)
+}
+
+// AfterCopy inserts copies of the elements in the selection after each
+// element in the set of matched elements. Unlike AfterSelection, the
+// elements of the given selection are always cloned, so they stay in place.
+func (s *Selection) AfterCopy(sel *Selection) *Selection {
+	return s.manipulateNodes(sel.Nodes, true, true, insertAfter)
 }
 
 // Append appends the elements specified by the selector to the end of each element
@@ -65,8 +67,11 @@ func (s *Selection) AfterNodes(ns ...*html.Node) *Selection {
 // 3) If there are multiple locations to append to, cloned nodes will be
 // appended to all target locations except the last one, which will be moved
 // as noted in (2).
+//
+// To always insert clones and leave the elements in place, use the ...Copy
+// methods (AppendCopy, PrependCopy, AfterCopy, BeforeCopy) or CopyTo.
 func (s *Selection) Append(selector string) *Selection {
-	return s.AppendMatcher(cascadia.MustCompile(selector))
+	return s.AppendMatcher(compileMatcher(selector, s))
 }
 
 // AppendMatcher appends the elements specified by the matcher to the end of each element
@@ -94,16 +99,21 @@ func (s *Selection) AppendHtml(html string) *Selection {
 //
 // This follows the same rules as Selection.Append.
 func (s *Selection) AppendNodes(ns ...*html.Node) *Selection {
-	return s.manipulateNodes(ns, false, func(sn *html.Node, n *html.Node) {
-		sn.AppendChild(n)
-	})
+	return s.manipulateNodes(ns, false, false, appendChild)
+}
+
+// AppendCopy appends copies of the elements in the selection to the end of
+// each element in the set of matched elements. Unlike AppendSelection, the
+// elements of the given selection are always cloned, so they stay in place.
+func (s *Selection) AppendCopy(sel *Selection) *Selection {
+	return s.manipulateNodes(sel.Nodes, false, true, appendChild)
 }
 
 // Before inserts the matched elements before each element in the set of matched elements.
 //
 // This follows the same rules as Selection.Append.
 func (s *Selection) Before(selector string) *Selection {
-	return s.BeforeMatcher(cascadia.MustCompile(selector))
+	return s.BeforeMatcher(compileMatcher(selector, s))
 }
 
 // BeforeMatcher inserts the matched elements before each element in the set of matched elements.
@@ -132,11 +142,14 @@ func (s *Selection) BeforeHtml(html string) *Selection {
 //
 // This follows the same rules as Selection.Append.
 func (s *Selection) BeforeNodes(ns ...*html.Node) *Selection {
-	return s.manipulateNodes(ns, false, func(sn *html.Node, n *html.Node) {
-		if sn.Parent != nil {
-			sn.Parent.InsertBefore(n, sn)
-		}
-	})
+	return s.manipulateNodes(ns, false, false, insertBefore)
+}
+
+// BeforeCopy inserts copies of the elements in the selection before each
+// element in the set of matched elements. Unlike BeforeSelection, the
+// elements of the given selection are always cloned, so they stay in place.
+func (s *Selection) BeforeCopy(sel *Selection) *Selection {
+	return s.manipulateNodes(sel.Nodes, false, true, insertBefore)
 }
 
 // Clone creates a deep copy of the set of matched nodes. The new nodes will not be
@@ -147,6 +160,38 @@ func (s *Selection) Clone() *Selection {
 	return ns
 }
 
+// CopyTo appends copies of the set of matched elements to the end of each
+// element in the target Selection. The matched elements stay in place. It
+// returns a new Selection object containing all the inserted copies.
+func (s *Selection) CopyTo(target *Selection) *Selection {
+	var nodes []*html.Node
+
+	for _, tn := range target.Nodes {
+		for _, n := range cloneNodes(s.Nodes) {
+			appendChild(s.document, tn, n)
+			nodes = append(nodes, n)
+		}
+	}
+
+	return pushStack(s, nodes)
+}
+
+// Detach removes the set of matched elements from the document, like Remove.
+// It returns a new Selection object containing the detached elements, which
+// can be inserted back in a document using e.g. AppendSelection or MoveTo.
+func (s *Selection) Detach() *Selection {
+	return pushStack(s, s.Remove().Nodes)
+}
+
+// MoveTo appends the set of matched elements to the end of each element in
+// the target Selection, removing them from their current location. If there
+// are multiple target elements, clones are appended to all of them except
+// the last one, as with Append. It returns the original set of elements.
+func (s *Selection) MoveTo(target *Selection) *Selection {
+	target.AppendSelection(s)
+	return s
+}
+
 // Empty removes all children nodes from the set of matched elements.
 // It returns the children nodes in a new Selection.
 func (s *Selection) Empty() *Selection {
@@ -154,7 +199,7 @@ func (s *Selection) Empty() *Selection {
 
 	for _, n := range s.Nodes {
 		for c := n.FirstChild; c != nil; c = n.FirstChild {
-			n.RemoveChild(c)
+			s.document.removeChild(c)
 			nodes = append(nodes, c)
 		}
 	}
@@ -165,7 +210,7 @@ func (s *Selection) Empty() *Selection {
 // Prepend prepends the elements specified by the selector to each element in
 // the set of matched elements, following the same rules as Append.
 func (s *Selection) Prepend(selector string) *Selection {
-	return s.PrependMatcher(cascadia.MustCompile(selector))
+	return s.PrependMatcher(compileMatcher(selector, s))
 }
 
 // PrependMatcher prepends the elements specified by the matcher to each
@@ -194,20 +239,21 @@ func (s *Selection) PrependHtml(html string) *Selection {
 //
 // This follows the same rules as Selection.Append.
 func (s *Selection) PrependNodes(ns ...*html.Node) *Selection {
-	return s.manipulateNodes(ns, true, func(sn *html.Node, n *html.Node) {
-		// sn.FirstChild may be nil, in which case this functions like
-		// sn.AppendChild()
-		sn.InsertBefore(n, sn.FirstChild)
-	})
+	return s.manipulateNodes(ns, true, false, prependChild)
+}
+
+// PrependCopy prepends copies of the elements in the selection to each
+// element in the set of matched elements. Unlike PrependSelection, the
+// elements of the given selection are always cloned, so they stay in place.
+func (s *Selection) PrependCopy(sel *Selection) *Selection {
+	return s.manipulateNodes(sel.Nodes, true, true, prependChild)
 }
 
 // Remove removes the set of matched elements from the document.
 // It returns the same selection, now consisting of nodes not in the document.
 func (s *Selection) Remove() *Selection {
 	for _, n := range s.Nodes {
-		if n.Parent != nil {
-			n.Parent.RemoveChild(n)
-		}
+		s.document.removeChild(n)
 	}
 
 	return s
@@ -216,7 +262,7 @@ func (s *Selection) Remove() *Selection {
 // RemoveFiltered removes the set of matched elements by selector.
 // It returns the Selection of removed nodes.
 func (s *Selection) RemoveFiltered(selector string) *Selection {
-	return s.RemoveMatcher(cascadia.MustCompile(selector))
+	return s.RemoveMatcher(compileMatcher(selector, s))
 }
 
 // RemoveMatcher removes the set of matched elements.
@@ -231,7 +277,7 @@ func (s *Selection) RemoveMatcher(m Matcher) *Selection {
 //
 // This follows the same rules as Selection.Append.
 func (s *Selection) ReplaceWith(selector string) *Selection {
-	return s.ReplaceWithMatcher(cascadia.MustCompile(selector))
+	return s.ReplaceWithMatcher(compileMatcher(selector, s))
 }
 
 // ReplaceWithMatcher replaces each element in the set of matched elements with
@@ -271,6 +317,30 @@ func (s *Selection) ReplaceWithNodes(ns ...*html.Node) *Selection {
 	return s.Remove()
 }
 
+// SetText sets the text contents of each element in the set of matched
+// elements, like jQuery's text(text): the children of the elements are
+// replaced by a single text node, or removed if text is empty. The text and
+// comment nodes of the set (as returned by Contents, for example) get their
+// data changed in place instead.
+// It returns the original selection.
+func (s *Selection) SetText(text string) *Selection {
+	for _, n := range s.Nodes {
+		switch n.Type {
+		case html.ElementNode:
+			for c := n.FirstChild; c != nil; c = n.FirstChild {
+				s.document.removeChild(c)
+			}
+			if text != "" {
+				s.document.insertChild(n, &html.Node{Type: html.TextNode, Data: text}, nil)
+			}
+		case html.TextNode, html.CommentNode:
+			s.document.setData(n, text)
+		}
+	}
+
+	return s
+}
+
 // Unwrap removes the parents of the set of matched elements, leaving the matched
 // elements (and their siblings, if any) in their place.
 // It returns the original selection.
@@ -293,7 +363,7 @@ func (s *Selection) Unwrap() *Selection {
 //
 // It returns the original set of elements.
 func (s *Selection) Wrap(selector string) *Selection {
-	return s.WrapMatcher(cascadia.MustCompile(selector))
+	return s.WrapMatcher(compileMatcher(selector, s))
 }
 
 // WrapMatcher wraps each element in the set of matched elements inside the
@@ -345,7 +415,7 @@ func (s *Selection) wrapNodes(ns ...*html.Node) *Selection {
 //
 // It returns the original set of elements.
 func (s *Selection) WrapAll(selector string) *Selection {
-	return s.WrapAllMatcher(cascadia.MustCompile(selector))
+	return s.WrapAllMatcher(compileMatcher(selector, s))
 }
 
 // WrapAllMatcher wraps a single HTML structure, matched by the given Matcher,
@@ -396,8 +466,8 @@ func (s *Selection) WrapAllNode(n *html.Node) *Selection {
 
 	first := s.Nodes[0]
 	if first.Parent != nil {
-		first.Parent.InsertBefore(wrap, first)
-		first.Parent.RemoveChild(first)
+		s.document.insertChild(first.Parent, wrap, first)
+		s.document.removeChild(first)
 	}
 
 	for c := getFirstChildEl(wrap); c != nil; c = getFirstChildEl(wrap) {
@@ -415,7 +485,7 @@ func (s *Selection) WrapAllNode(n *html.Node) *Selection {
 //
 // It returns the original set of elements.
 func (s *Selection) WrapInner(selector string) *Selection {
-	return s.WrapInnerMatcher(cascadia.MustCompile(selector))
+	return s.WrapInnerMatcher(compileMatcher(selector, s))
 }
 
 // WrapInnerMatcher wraps an HTML structure, matched by the given selector,
@@ -482,6 +552,29 @@ func parseHtml(h string) []*html.Node {
 	return nodes
 }
 
+// Insertion callbacks for manipulateNodes.
+func appendChild(d *Document, sn *html.Node, n *html.Node) {
+	d.insertChild(sn, n, nil)
+}
+
+func prependChild(d *Document, sn *html.Node, n *html.Node) {
+	// sn.FirstChild may be nil, in which case this functions like
+	// appendChild()
+	d.insertChild(sn, n, sn.FirstChild)
+}
+
+func insertAfter(d *Document, sn *html.Node, n *html.Node) {
+	if sn.Parent != nil {
+		d.insertChild(sn.Parent, n, sn.NextSibling)
+	}
+}
+
+func insertBefore(d *Document, sn *html.Node, n *html.Node) {
+	if sn.Parent != nil {
+		d.insertChild(sn.Parent, n, sn)
+	}
+}
+
 // Get the first child that is an ElementNode
 func getFirstChildEl(n *html.Node) *html.Node {
 	c := n.FirstChild
@@ -520,29 +613,30 @@ func cloneNode(n *html.Node) *html.Node {
 	return nn
 }
 
-func (s *Selection) manipulateNodes(ns []*html.Node, reverse bool,
-	f func(sn *html.Node, n *html.Node)) *Selection {
+func (s *Selection) manipulateNodes(ns []*html.Node, reverse bool, alwaysClone bool,
+	f func(d *Document, sn *html.Node, n *html.Node)) *Selection {
 
 	lasti := s.Size() - 1
 
 	// net.Html doesn't provide document fragments for insertion, so to get
 	// things in the correct order with After() and Prepend(), the callback
-	// needs to be called on the reverse of the nodes.
+	// needs to be called on the reverse of the nodes. The reversal is done on
+	// a copy, the slice may be the Nodes of the caller's Selection.
 	if reverse {
-		for i, j := 0, len(ns)-1; i < j; i, j = i+1, j-1 {
-			ns[i], ns[j] = ns[j], ns[i]
+		rns := make([]*html.Node, len(ns))
+		for i, n := range ns {
+			rns[len(ns)-1-i] = n
 		}
+		ns = rns
 	}
 
 	for i, sn := range s.Nodes {
 		for _, n := range ns {
-			if i != lasti {
-				f(sn, cloneNode(n))
+			if i != lasti || alwaysClone {
+				f(s.document, sn, cloneNode(n))
 			} else {
-				if n.Parent != nil {
-					n.Parent.RemoveChild(n)
-				}
-				f(sn, n)
+				s.document.removeChild(n)
+				f(s.document, sn, n)
 			}
 		}
 	}
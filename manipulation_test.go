@@ -48,6 +48,25 @@ func TestAfterSelection(t *testing.T) {
 	printSel(t, doc.Selection)
 }
 
+func TestAfterSelectionKeepsOrder(t *testing.T) {
+	doc := Doc2Clone()
+	sel := doc.Find("#nf1, #nf2, #nf3")
+	doc.Find("#main").AfterSelection(sel)
+
+	assertSelectionIs(t, sel, "#nf1", "#nf2", "#nf3")
+	assertLength(t, doc.Find("#main + #nf1 + #nf2 + #nf3").Nodes, 1)
+	printSel(t, doc.Selection)
+}
+
+func TestAfterCopy(t *testing.T) {
+	doc := Doc2Clone()
+	doc.Find("#main").AfterCopy(doc.Find("#nf1, #nf2"))
+
+	assertLength(t, doc.Find("#foot #nf1, #foot #nf2").Nodes, 2)
+	assertLength(t, doc.Find("#main + #nf1 + #nf2").Nodes, 1)
+	printSel(t, doc.Selection)
+}
+
 func TestAfterHtml(t *testing.T) {
 	doc := Doc2Clone()
 	doc.Find("#main").AfterHtml("<strong>new node</strong>")
@@ -105,6 +124,15 @@ func TestAppendClone(t *testing.T) {
 	printSel(t, doc.Selection)
 }
 
+func TestAppendCopy(t *testing.T) {
+	doc := Doc2Clone()
+	doc.Find("#n1, #n2").AppendCopy(doc.Find("#nf1"))
+
+	assertLength(t, doc.Find("#foot > #nf1").Nodes, 1)
+	assertLength(t, doc.Find("#n1 > #nf1, #n2 > #nf1").Nodes, 2)
+	printSel(t, doc.Selection)
+}
+
 func TestAppendHtml(t *testing.T) {
 	doc := Doc2Clone()
 	doc.Find("div").AppendHtml("<strong>new node</strong>")
@@ -143,6 +171,15 @@ func TestBeforeSelection(t *testing.T) {
 	printSel(t, doc.Selection)
 }
 
+func TestBeforeCopy(t *testing.T) {
+	doc := Doc2Clone()
+	doc.Find("#n1").BeforeCopy(doc.Find("#nf1, #nf2"))
+
+	assertLength(t, doc.Find("#foot #nf1, #foot #nf2").Nodes, 2)
+	assertLength(t, doc.Find("#nf1 + #nf2 + #n1").Nodes, 1)
+	printSel(t, doc.Selection)
+}
+
 func TestBeforeHtml(t *testing.T) {
 	doc := Doc2Clone()
 	doc.Find("#main").BeforeHtml("<strong>new node</strong>")
@@ -151,6 +188,54 @@ func TestBeforeHtml(t *testing.T) {
 	printSel(t, doc.Selection)
 }
 
+func TestCopyTo(t *testing.T) {
+	doc := Doc2Clone()
+	src := doc.Find("#nf1, #nf2")
+	copies := src.CopyTo(doc.Find("#n1, #n2"))
+
+	assertLength(t, copies.Nodes, 4)
+	assertLength(t, doc.Find("#foot > #nf1, #foot > #nf2").Nodes, 2)
+	assertLength(t, doc.Find("#n1 > #nf1 + #nf2, #n2 > #nf1 + #nf2").Nodes, 2)
+	assertEqual(t, copies.End(), src)
+	for _, n := range copies.Nodes {
+		if isInSlice(src.Nodes, n) {
+			t.Error("Expected CopyTo to return the copies, not the source nodes.")
+		}
+	}
+	printSel(t, doc.Selection)
+}
+
+func TestDetach(t *testing.T) {
+	doc := Doc2Clone()
+	src := doc.Find("#nf1, #nf2")
+	sel := src.Detach()
+
+	assertLength(t, sel.Nodes, 2)
+	assertEqual(t, sel.End(), src)
+	assertLength(t, doc.Find("#nf1, #nf2").Nodes, 0)
+	for _, n := range sel.Nodes {
+		if n.Parent != nil {
+			t.Error("Expected detached node to have no parent.")
+		}
+	}
+
+	// Reinsert
+	doc.Find("#main").AppendSelection(sel)
+	assertLength(t, doc.Find("#main > #nf1 + #nf2").Nodes, 1)
+	printSel(t, doc.Selection)
+}
+
+func TestMoveTo(t *testing.T) {
+	doc := Doc2Clone()
+	sel := doc.Find("#nf1, #nf2")
+	res := sel.MoveTo(doc.Find("#n1"))
+
+	assertEqual(t, res, sel)
+	assertLength(t, doc.Find("#foot #nf1, #foot #nf2").Nodes, 0)
+	assertLength(t, doc.Find("#n1 > #nf1 + #nf2").Nodes, 1)
+	printSel(t, doc.Selection)
+}
+
 func TestEmpty(t *testing.T) {
 	doc := Doc2Clone()
 	s := doc.Find("#main").Empty()
@@ -210,6 +295,15 @@ func TestPrependClone(t *testing.T) {
 	printSel(t, doc.Selection)
 }
 
+func TestPrependCopy(t *testing.T) {
+	doc := Doc2Clone()
+	doc.Find("#main").PrependCopy(doc.Find("#nf1, #nf2"))
+
+	assertLength(t, doc.Find("#foot #nf1, #foot #nf2").Nodes, 2)
+	assertLength(t, doc.Find("#main > #nf1:first-child + #nf2").Nodes, 1)
+	printSel(t, doc.Selection)
+}
+
 func TestPrependHtml(t *testing.T) {
 	doc := Doc2Clone()
 	doc.Find("div").PrependHtml("<strong>new node</strong>")
@@ -289,6 +383,25 @@ func TestReplaceWithSelection(t *testing.T) {
 	printSel(t, doc.Selection)
 }
 
+func TestSetText(t *testing.T) {
+	doc := Doc2Clone()
+	sel := doc.Find("#main").SetText("a < b")
+
+	assertSelectionIs(t, sel, "#main")
+	assertLength(t, doc.Find("#main").Children().Nodes, 0)
+	if h, _ := sel.Html(); h != "a &lt; b" {
+		t.Errorf("expected escaped text, got %q", h)
+	}
+
+	sel.Contents().SetText("changed")
+	if txt := sel.Text(); txt != "changed" {
+		t.Errorf("expected text node data to be set, got %q", txt)
+	}
+
+	sel.SetText("")
+	assertLength(t, sel.Contents().Nodes, 0)
+}
+
 func TestUnwrap(t *testing.T) {
 	doc := Doc2Clone()
 
@@ -0,0 +1,176 @@
+package goquery
+
+import (
+	"golang.org/x/net/html"
+)
+
+// MorphOptions configures Selection.Morph.
+type MorphOptions struct {
+	// KeyAttrs are the attributes identifying the elements among their
+	// siblings, in addition to id, such as "data-key". The first attribute
+	// that an element has is its key.
+	KeyAttrs []string
+	// Inner morphs the children of the elements into the nodes of the new
+	// content, instead of the elements themselves into its first node.
+	Inner bool
+}
+
+// Morph updates each element in the set of matched elements in place so that
+// it becomes identical to the first node of newContent, with the minimal
+// changes: only the changed attributes and text are set, and the nodes are
+// kept whenever possible, so the Selections of the document remain valid.
+// With MorphOptions.Inner, the children of the elements are morphed into the
+// nodes of newContent instead. The nodes of newContent are left untouched,
+// the nodes added to the document are copies.
+//
+// The children are reconciled in order. A new child with a key (its id, or one
+// of MorphOptions.KeyAttrs) is matched with the old child of the same tag
+// name and key, which is moved in place if required. A new child without a
+// key is matched with the next old child of the same type and tag name,
+// without a key either. The matched children are morphed in turn, a copy of
+// the unmatched new children is inserted, and the unmatched old children are
+// removed. An element whose tag name differs from the new node is replaced by
+// a copy of the node.
+//
+// The changes are made with the same functions as the manipulation and
+// property methods, so they are recorded by the transactions (see
+// Document.Begin) and reported to the observers (see Document.Observe) of the
+// document. If newContent is empty, the elements are left untouched, or
+// emptied with MorphOptions.Inner.
+//
+// It returns the original selection.
+func (s *Selection) Morph(newContent *Selection, opts MorphOptions) *Selection {
+	m := &morpher{doc: s.document, keyAttrs: append([]string{"id"}, opts.KeyAttrs...)}
+
+	for _, n := range s.Nodes {
+		if opts.Inner {
+			m.morphChildren(n, newContent.Nodes)
+		} else if len(newContent.Nodes) > 0 {
+			m.morphNode(n, newContent.Nodes[0])
+		}
+	}
+
+	return s
+}
+
+type morpher struct {
+	doc      *Document
+	keyAttrs []string
+}
+
+// Morphs the node into the new node, replacing it if they don't match.
+func (m *morpher) morphNode(n, nn *html.Node) {
+	if !sameNodeKind(n, nn) {
+		if p := n.Parent; p != nil {
+			m.doc.insertChild(p, cloneNode(nn), n)
+			m.doc.removeChild(n)
+		}
+		return
+	}
+
+	switch n.Type {
+	case html.TextNode, html.CommentNode:
+		m.doc.setData(n, nn.Data)
+		return
+	case html.ElementNode:
+		if !equalAttrs(n.Attr, nn.Attr) {
+			m.doc.changeAttrs(n, func() {
+				n.Attr = append([]html.Attribute(nil), nn.Attr...)
+			})
+		}
+	}
+
+	m.morphChildren(n, childNodes(nn))
+}
+
+// Morphs the children of the node into the new nodes.
+func (m *morpher) morphChildren(n *html.Node, nns []*html.Node) {
+	// The old children with a key that the new nodes have as well
+	newKeys := make(map[string]bool)
+	for _, nn := range nns {
+		if k := m.key(nn); k != "" {
+			newKeys[k] = true
+		}
+	}
+	keyed := make(map[string]*html.Node)
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if k := m.key(c); newKeys[k] {
+			if _, ok := keyed[k]; !ok {
+				keyed[k] = c
+			}
+		}
+	}
+
+	used := make(map[*html.Node]bool)
+	old := childNodes(n)
+	cur := n.FirstChild
+	for _, nn := range nns {
+		// Skip the old children already used, moved before the cursor
+		for cur != nil && used[cur] {
+			cur = cur.NextSibling
+		}
+
+		var match *html.Node
+		if k := m.key(nn); k != "" {
+			if c := keyed[k]; c != nil && !used[c] {
+				match = c
+			}
+		} else {
+			match = m.findSoftMatch(cur, nn, used)
+		}
+
+		if match == nil {
+			m.doc.insertChild(n, cloneNode(nn), cur)
+			continue
+		}
+
+		used[match] = true
+		if match == cur || m.key(match) == "" {
+			// The old children skipped to get to a match without a key are
+			// left behind the cursor, to be moved if they have a key, or
+			// removed.
+			cur = match.NextSibling
+		} else {
+			m.doc.removeChild(match)
+			m.doc.insertChild(n, match, cur)
+		}
+		m.morphNode(match, nn)
+	}
+
+	for _, c := range old {
+		if !used[c] && c.Parent == n {
+			m.doc.removeChild(c)
+		}
+	}
+}
+
+// Returns the first old child from c that is not used and has the same type
+// and tag name as the new node, and no key either. The children with a key
+// matched by other new nodes are skipped.
+func (m *morpher) findSoftMatch(c, nn *html.Node, used map[*html.Node]bool) *html.Node {
+	for ; c != nil; c = c.NextSibling {
+		if !used[c] && m.key(c) == "" && sameNodeKind(c, nn) {
+			return c
+		}
+	}
+	return nil
+}
+
+// Returns the key of the element, its tag name and first key attribute, or
+// an empty string if it has none.
+func (m *morpher) key(n *html.Node) string {
+	if n.Type != html.ElementNode {
+		return ""
+	}
+	for _, name := range m.keyAttrs {
+		if val, ok := getAttributeValue(name, n); ok && val != "" {
+			return nodeKey(n) + " " + name + "=" + val
+		}
+	}
+	return ""
+}
+
+// Checks if the nodes have the same type and tag name.
+func sameNodeKind(a, b *html.Node) bool {
+	return a.Type == b.Type && (a.Type != html.ElementNode || (a.Namespace == b.Namespace && a.Data == b.Data))
+}
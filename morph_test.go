@@ -0,0 +1,112 @@
+package goquery
+
+import (
+	"testing"
+)
+
+func TestMorph(t *testing.T) {
+	doc := loadString(t, `<ul id="list" class="a"><li id="i1">one</li><li id="i2">two</li><li id="i3">three</li></ul>`)
+	newDoc := loadString(t, `<ul id="list" class="b"><li id="i3">three</li><li id="i1">uno</li><li id="i4">four</li></ul>`)
+	ul := doc.Find("ul")
+	i1, i3 := doc.Find("#i1"), doc.Find("#i3")
+	text := i1.Contents()
+
+	ul.Morph(newDoc.Find("ul"), MorphOptions{})
+
+	expected, _ := newDoc.Find("body").Html()
+	if got, _ := doc.Find("body").Html(); got != expected {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+	if !i1.IsSelection(doc.Find("#i1")) || !i3.IsSelection(doc.Find("#i3")) || !text.IsSelection(doc.Find("#i1").Contents()) {
+		t.Error("expected the matched nodes to be kept")
+	}
+	assertLength(t, doc.Find("#i2").Nodes, 0)
+	if changes := Diff(doc.Selection, newDoc.Selection); len(changes) != 0 {
+		t.Errorf("expected no difference left, got:\n%s", FormatDiff(changes))
+	}
+	// The new content is left untouched
+	assertLength(t, newDoc.Find("li").Nodes, 3)
+}
+
+func TestMorphMinimalChanges(t *testing.T) {
+	doc := loadString(t, `<div><p title="t" class="a">x</p><p>y</p><span>z</span></div>`)
+	newDoc := loadString(t, `<div><p title="t" class="b">x</p><span>z</span></div>`)
+
+	var recs []MutationRecord
+	doc.Observe(func(r MutationRecord) {
+		recs = append(recs, r)
+	})
+	span := doc.Find("span")
+	doc.Find("div").Morph(newDoc.Find("div"), MorphOptions{})
+
+	if len(recs) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(recs))
+	}
+	if r := recs[0]; r.Type != MutationAttributes || r.AttributeName != "class" {
+		t.Errorf("expected a class change, got %+v", r)
+	}
+	if r := recs[1]; r.Type != MutationChildList || len(r.RemovedNodes) != 1 || r.RemovedNodes[0].Data != "p" {
+		t.Errorf("expected a removed p, got %+v", r)
+	}
+	if !span.IsSelection(doc.Find("span")) {
+		t.Error("expected the span to be kept")
+	}
+}
+
+func TestMorphKeyAttrs(t *testing.T) {
+	doc := loadString(t, `<ul><li data-key="a">A</li><li data-key="b">B</li></ul>`)
+	newDoc := loadString(t, `<ul><li data-key="b">B</li><li data-key="a">A</li></ul>`)
+	a := doc.Find("li").First()
+
+	doc.Find("ul").Morph(newDoc.Find("ul"), MorphOptions{KeyAttrs: []string{"data-key"}})
+
+	if !a.IsSelection(doc.Find("li").Last()) {
+		t.Error("expected the keyed element to be moved")
+	}
+	if txt := doc.Find("ul").Text(); txt != "BA" {
+		t.Errorf("expected BA, got %s", txt)
+	}
+}
+
+func TestMorphInner(t *testing.T) {
+	doc := loadString(t, `<div id="d1"><p>a</p></div><div id="d2"></div>`)
+	newDoc := loadString(t, `<p>b</p><b>c</b>`)
+	p := doc.Find("#d1 p")
+
+	doc.Find("div").Morph(newDoc.Find("body").Contents(), MorphOptions{Inner: true})
+
+	for _, id := range []string{"#d1", "#d2"} {
+		if h, _ := doc.Find(id).Html(); h != "<p>b</p><b>c</b>" {
+			t.Errorf("%s: unexpected content %s", id, h)
+		}
+	}
+	if !p.IsSelection(doc.Find("#d1 p")) {
+		t.Error("expected the paragraph to be kept")
+	}
+
+	doc.Find("#d1").Morph(newDoc.Find("none"), MorphOptions{Inner: true})
+	assertLength(t, doc.Find("#d1").Contents().Nodes, 0)
+}
+
+func TestMorphReplace(t *testing.T) {
+	doc := loadString(t, `<div><p>a</p></div>`)
+	newDoc := loadString(t, `<section><p>a</p></section>`)
+
+	doc.Find("div").Morph(newDoc.Find("section"), MorphOptions{})
+	assertLength(t, doc.Find("div").Nodes, 0)
+	assertLength(t, doc.Find("section > p").Nodes, 1)
+}
+
+func TestMorphRollback(t *testing.T) {
+	doc := Doc2Clone()
+	before, _ := doc.Html()
+
+	tx := doc.Begin()
+	doc.Find("#main").Morph(loadString(t, `<div id="main"><div id="n3"></div><p>new</p></div>`).Find("#main"), MorphOptions{})
+	if e := tx.Rollback(); e != nil {
+		t.Fatal(e)
+	}
+	if h, _ := doc.Html(); h != before {
+		t.Errorf("expected the document to be restored, got %s", h)
+	}
+}
@@ -0,0 +1,218 @@
+package goquery
+
+import (
+	"golang.org/x/net/html"
+)
+
+// MutationType is the type of a change reported to an Observer.
+type MutationType int
+
+const (
+	// MutationChildList is a node added to or removed from the children of
+	// the target.
+	MutationChildList MutationType = iota
+	// MutationAttributes is an attribute of the target added, changed or
+	// removed.
+	MutationAttributes
+	// MutationCharacterData is a change of the data of the target, a text or
+	// comment node.
+	MutationCharacterData
+)
+
+// String returns the name of the type, as in the DOM's MutationRecord.
+func (t MutationType) String() string {
+	switch t {
+	case MutationChildList:
+		return "childList"
+	case MutationAttributes:
+		return "attributes"
+	case MutationCharacterData:
+		return "characterData"
+	}
+	return "unknown"
+}
+
+// MutationRecord describes a change made to a Document, like the DOM's
+// MutationRecord. Each record reports a single node added or removed, or a
+// single attribute changed, so a method changing many nodes (such as Empty)
+// produces a record for each of them.
+type MutationRecord struct {
+	// Type is the type of the change.
+	Type MutationType
+	// Target is the parent of the added or removed node, the element of the
+	// changed attribute, or the text or comment node of the changed data.
+	Target *html.Node
+	// AddedNodes and RemovedNodes hold the node added to or removed from the
+	// children of the target.
+	AddedNodes   []*html.Node
+	RemovedNodes []*html.Node
+	// PreviousSibling and NextSibling are the siblings of the added or
+	// removed node.
+	PreviousSibling *html.Node
+	NextSibling     *html.Node
+	// AttributeName is the qualified name of the changed attribute.
+	AttributeName string
+	// OldValue is the previous value of the attribute, empty if it was added,
+	// or the previous data of the node.
+	OldValue string
+}
+
+// Observer receives the changes made to a Document, as returned by
+// Document.Observe.
+type Observer struct {
+	doc          *Document
+	f            func(MutationRecord)
+	targeted     bool
+	targets      []*html.Node
+	subtree      bool
+	attrs        []string
+	disconnected bool
+}
+
+// Observe registers a function to be called with a MutationRecord for each
+// change made to the document through the manipulation methods
+// (manipulation.go, including SetText) and the attribute, class, style, data
+// and property setters (property.go), called on Selections of this document,
+// and for each change reverted by Tx.Rollback. Changes made directly to the
+// html.Node values, or through a Selection of another Document, are not
+// reported. The function is called synchronously, right after the change,
+// and the changes it makes itself are reported as well.
+//
+// By default, all the changes are reported. The returned Observer can be used
+// to restrict them with Target and AttributeFilter, and to stop receiving them
+// with Disconnect.
+func (d *Document) Observe(f func(MutationRecord)) *Observer {
+	o := &Observer{doc: d, f: f}
+	d.observers = append(d.observers, o)
+	return o
+}
+
+// Target restricts the reported changes to the ones whose target is one of
+// the nodes of the selection or, if subtree is true, one of their
+// descendants. It replaces the targets of a previous call. It returns the
+// Observer so that calls can be chained.
+func (o *Observer) Target(sel *Selection, subtree bool) *Observer {
+	o.targeted = true
+	o.targets = append([]*html.Node(nil), sel.Nodes...)
+	o.subtree = subtree
+	return o
+}
+
+// AttributeFilter restricts the reported attribute changes to the given
+// qualified attribute names. The other types of changes are not affected.
+// It replaces the names of a previous call, and no name removes the
+// restriction. It returns the Observer so that calls can be chained.
+func (o *Observer) AttributeFilter(names ...string) *Observer {
+	o.attrs = names
+	return o
+}
+
+// Disconnect stops reporting the changes to the Observer.
+func (o *Observer) Disconnect() {
+	if o.disconnected {
+		return
+	}
+	o.disconnected = true
+
+	// The observers are copied, the slice may be iterated over by notify
+	d := o.doc
+	obs := make([]*Observer, 0, len(d.observers))
+	for _, ob := range d.observers {
+		if ob != o {
+			obs = append(obs, ob)
+		}
+	}
+	d.observers = obs
+}
+
+// Checks if the record must be reported to the Observer.
+func (o *Observer) accepts(r *MutationRecord) bool {
+	if o.disconnected {
+		return false
+	}
+	if r.Type == MutationAttributes && len(o.attrs) > 0 {
+		found := false
+		for _, name := range o.attrs {
+			if name == r.AttributeName {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if !o.targeted {
+		return true
+	}
+
+	for n := r.Target; n != nil; n = n.Parent {
+		for _, t := range o.targets {
+			if n == t {
+				return true
+			}
+		}
+		if !o.subtree {
+			break
+		}
+	}
+	return false
+}
+
+// Checks if the document has observers. The document may be nil, for
+// Selections not tied to a document.
+func (d *Document) observed() bool {
+	return d != nil && len(d.observers) > 0
+}
+
+func (d *Document) notify(r MutationRecord) {
+	for _, o := range d.observers {
+		if o.accepts(&r) {
+			o.f(r)
+		}
+	}
+}
+
+// Reports the differences between the old attributes of the node and its
+// current ones: the changed and removed attributes in their old order, then
+// the added ones.
+func (d *Document) notifyAttrs(n *html.Node, old []html.Attribute) {
+	for i, a := range old {
+		name := qualifiedAttrName(a)
+		if _, dup := findAttr(old[:i], name); dup {
+			continue
+		}
+		if val, ok := findAttr(n.Attr, name); !ok || val != a.Val {
+			d.notify(MutationRecord{
+				Type:          MutationAttributes,
+				Target:        n,
+				AttributeName: name,
+				OldValue:      a.Val,
+			})
+		}
+	}
+
+	for i, a := range n.Attr {
+		name := qualifiedAttrName(a)
+		if _, dup := findAttr(n.Attr[:i], name); dup {
+			continue
+		}
+		if _, ok := findAttr(old, name); !ok {
+			d.notify(MutationRecord{
+				Type:          MutationAttributes,
+				Target:        n,
+				AttributeName: name,
+			})
+		}
+	}
+}
+
+// Returns the value of the first attribute with the qualified name.
+func findAttr(attrs []html.Attribute, name string) (string, bool) {
+	for _, a := range attrs {
+		if qualifiedAttrName(a) == name {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,146 @@
+package goquery
+
+import (
+	"testing"
+)
+
+func TestObserveChildList(t *testing.T) {
+	doc := Doc2Clone()
+	var recs []MutationRecord
+	doc.Observe(func(r MutationRecord) {
+		recs = append(recs, r)
+	})
+
+	n1, n2, n3 := doc.Find("#n1").Get(0), doc.Find("#n2").Get(0), doc.Find("#n3").Get(0)
+	main := doc.Find("#main").Get(0)
+	doc.Find("#n2").Remove()
+	doc.Find("#n3").AppendHtml("<p>a</p>")
+
+	if len(recs) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(recs))
+	}
+	r := recs[0]
+	if r.Type != MutationChildList || r.Target != main || len(r.RemovedNodes) != 1 || r.RemovedNodes[0] != n2 {
+		t.Errorf("unexpected removal record %+v", r)
+	}
+	// The whitespace text nodes are the siblings
+	if r.PreviousSibling.PrevSibling != n1 || r.NextSibling.NextSibling != n3 {
+		t.Errorf("unexpected siblings in removal record %+v", r)
+	}
+	r = recs[1]
+	if r.Type != MutationChildList || r.Target != n3 || len(r.AddedNodes) != 1 || r.AddedNodes[0].Data != "p" {
+		t.Errorf("unexpected insertion record %+v", r)
+	}
+	if r.PreviousSibling != nil || r.NextSibling != nil {
+		t.Errorf("unexpected siblings in insertion record %+v", r)
+	}
+}
+
+func TestObserveAttributes(t *testing.T) {
+	doc := Doc2Clone()
+	var recs []MutationRecord
+	doc.Observe(func(r MutationRecord) {
+		recs = append(recs, r)
+	})
+
+	sel := doc.Find("#n1")
+	sel.AddClass("new").SetAttr("title", "t").RemoveAttr("id").SetAttr("title", "t")
+
+	expected := []struct {
+		name, old string
+	}{
+		{"class", "one even row"},
+		{"title", ""},
+		{"id", "n1"},
+	}
+	if len(recs) != len(expected) {
+		t.Fatalf("expected %d records, got %d", len(expected), len(recs))
+	}
+	for i, e := range expected {
+		r := recs[i]
+		if r.Type != MutationAttributes || r.Target != sel.Get(0) || r.AttributeName != e.name || r.OldValue != e.old {
+			t.Errorf("%d: expected %s=%q, got %+v", i, e.name, e.old, r)
+		}
+	}
+}
+
+func TestObserveCharacterData(t *testing.T) {
+	doc := loadString(t, "<p>old</p>")
+	var recs []MutationRecord
+	doc.Observe(func(r MutationRecord) {
+		recs = append(recs, r)
+	})
+
+	txt := doc.Find("p").Contents()
+	txt.SetText("new")
+	if len(recs) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(recs))
+	}
+	if r := recs[0]; r.Type != MutationCharacterData || r.Target != txt.Get(0) || r.OldValue != "old" {
+		t.Errorf("unexpected record %+v", r)
+	}
+	if s := recs[0].Type.String(); s != "characterData" {
+		t.Errorf("expected characterData, got %s", s)
+	}
+}
+
+func TestObserveTarget(t *testing.T) {
+	doc := Doc2Clone()
+	var direct, subtree int
+	doc.Observe(func(r MutationRecord) {
+		direct++
+	}).Target(doc.Find("#main"), false)
+	doc.Observe(func(r MutationRecord) {
+		subtree++
+	}).Target(doc.Find("#main"), true)
+
+	doc.Find("#n1").SetAttr("title", "t")
+	doc.Find("#n1").Remove()
+	doc.Find("#main").SetAttr("title", "t")
+	doc.Find("#foot").SetAttr("title", "t")
+
+	if direct != 2 {
+		t.Errorf("expected 2 direct records, got %d", direct)
+	}
+	if subtree != 3 {
+		t.Errorf("expected 3 subtree records, got %d", subtree)
+	}
+}
+
+func TestObserveAttributeFilter(t *testing.T) {
+	doc := Doc2Clone()
+	var names []string
+	doc.Observe(func(r MutationRecord) {
+		names = append(names, r.AttributeName)
+	}).AttributeFilter("class", "xlink:href")
+
+	doc.Find("#n1").SetAttr("title", "t").ToggleClass("one")
+	if len(names) != 1 || names[0] != "class" {
+		t.Errorf("expected a class record, got %v", names)
+	}
+}
+
+func TestObserveDisconnect(t *testing.T) {
+	doc := Doc2Clone()
+	var first, second int
+	var o2 *Observer
+	o1 := doc.Observe(func(r MutationRecord) {
+		first++
+		o2.Disconnect()
+	})
+	o2 = doc.Observe(func(r MutationRecord) {
+		second++
+	})
+
+	doc.Find("#n1").SetAttr("title", "t")
+	o1.Disconnect()
+	o1.Disconnect()
+	doc.Find("#n1").SetAttr("title", "u")
+
+	if first != 1 || second != 0 {
+		t.Errorf("expected 1 and 0 records, got %d and %d", first, second)
+	}
+	if len(doc.observers) != 0 {
+		t.Errorf("expected no observer, got %d", len(doc.observers))
+	}
+}
@@ -2,17 +2,30 @@ package goquery
 
 import (
 	"bytes"
-	"regexp"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"sort"
 	"strings"
 
+	"code.google.com/p/cascadia"
 	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
 )
 
-var rxClassTrim = regexp.MustCompile("[\t\r\n]")
+var baseHrefMatcher = cascadia.MustCompile("base[href]")
+
+// ErrDataNotFound is returned by DataJSON when the requested data-* attribute
+// does not exist.
+var ErrDataNotFound = errors.New("goquery: data attribute not found")
 
 // Attr gets the specified attribute's value for the first element in the
 // Selection. To get the value for each element individually, use a looping
 // construct such as Each or Map method.
+//
+// Attributes in a namespace, such as xlink:href on SVG elements, are looked
+// up by their qualified name ("xlink:href"), and are not returned for their
+// local name alone ("href").
 func (s *Selection) Attr(attrName string) (val string, exists bool) {
 	if len(s.Nodes) == 0 {
 		return
@@ -20,10 +33,112 @@ func (s *Selection) Attr(attrName string) (val string, exists bool) {
 	return getAttributeValue(attrName, s.Nodes[0])
 }
 
+// AttrOr gets the specified attribute's value for the first element in the
+// Selection, or the default value if the attribute does not exist or the
+// Selection is empty.
+func (s *Selection) AttrOr(attrName, defaultValue string) string {
+	if val, ok := s.Attr(attrName); ok {
+		return val
+	}
+	return defaultValue
+}
+
+// Attrs returns all attributes of the first element in the Selection, keyed
+// by their qualified name. It returns nil if the Selection is empty.
+func (s *Selection) Attrs() map[string]string {
+	if len(s.Nodes) == 0 {
+		return nil
+	}
+
+	m := make(map[string]string, len(s.Nodes[0].Attr))
+	for _, a := range s.Nodes[0].Attr {
+		// The first attribute wins, as in the parser
+		name := qualifiedAttrName(a)
+		if _, ok := m[name]; !ok {
+			m[name] = a.Val
+		}
+	}
+	return m
+}
+
+// HasAttr determines whether any of the matched elements has the given
+// attribute.
+func (s *Selection) HasAttr(attrName string) bool {
+	for _, n := range s.Nodes {
+		if getAttributePtr(attrName, n) != nil {
+			return true
+		}
+	}
+	return false
+}
+
 // RemoveAttr removes the named attribute from each element in the set of matched elements.
 func (s *Selection) RemoveAttr(attrName string) *Selection {
 	for _, n := range s.Nodes {
-		removeAttr(n, attrName)
+		s.document.changeAttrs(n, func() {
+			removeAttr(n, attrName)
+		})
+	}
+
+	return s
+}
+
+// RemoveAttrs removes the named attributes from each element in the set of
+// matched elements.
+func (s *Selection) RemoveAttrs(attrNames ...string) *Selection {
+	return s.RemoveAttrsMatching(func(name string) bool {
+		for _, rname := range attrNames {
+			if name == rname {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// RemoveAttrsMatching removes the attributes for which the function returns
+// true from each element in the set of matched elements. The function
+// receives the qualified name of the attribute. For example, this removes
+// all event handler attributes:
+//
+//	sel.RemoveAttrsMatching(func(name string) bool {
+//	    return strings.HasPrefix(name, "on")
+//	})
+//
+// The order of the remaining attributes is preserved.
+func (s *Selection) RemoveAttrsMatching(f func(string) bool) *Selection {
+	for _, n := range s.Nodes {
+		s.document.changeAttrs(n, func() {
+			removeAttrsMatching(n, f)
+		})
+	}
+
+	return s
+}
+
+// RenameAttr renames the given attribute on each element in the set of
+// matched elements, keeping its value and position. If an attribute with the
+// new name already exists, it is replaced. Elements without the attribute
+// are left untouched.
+func (s *Selection) RenameAttr(oldName, newName string) *Selection {
+	if oldName == newName {
+		return s
+	}
+
+	for _, n := range s.Nodes {
+		if getAttributePtr(oldName, n) == nil {
+			continue
+		}
+		s.document.changeAttrs(n, func() {
+			// Remove the existing attribute while preserving the order of
+			// the others, then get the attribute to rename as it may have
+			// moved.
+			removeAttrsMatching(n, func(name string) bool {
+				return name == newName
+			})
+			attr := getAttributePtr(oldName, n)
+			attr.Namespace, attr.Key = splitAttrName(n, newName)
+		})
 	}
 
 	return s
@@ -32,12 +147,15 @@ func (s *Selection) RemoveAttr(attrName string) *Selection {
 // SetAttr sets the given attribute on each element in the set of matched elements.
 func (s *Selection) SetAttr(attrName, val string) *Selection {
 	for _, n := range s.Nodes {
-		attr := getAttributePtr(attrName, n)
-		if attr == nil {
-			n.Attr = append(n.Attr, html.Attribute{Key: attrName, Val: val})
-		} else {
-			attr.Val = val
-		}
+		s.document.changeAttrs(n, func() {
+			attr := getAttributePtr(attrName, n)
+			if attr == nil {
+				ns, key := splitAttrName(n, attrName)
+				n.Attr = append(n.Attr, html.Attribute{Namespace: ns, Key: key, Val: val})
+			} else {
+				attr.Val = val
+			}
+		})
 	}
 
 	return s
@@ -88,64 +206,155 @@ func (s *Selection) Html() (ret string, e error) {
 // AddClass adds the given class(es) to each element in the set of matched elements.
 // Multiple class names can be specified, separated by a space or via multiple arguments.
 func (s *Selection) AddClass(class ...string) *Selection {
-	classStr := strings.TrimSpace(strings.Join(class, " "))
-
-	if classStr == "" {
+	tcls := getClassesSlice(class...)
+	if len(tcls) == 0 {
 		return s
 	}
 
-	tcls := getClassesSlice(classStr)
 	for _, n := range s.Nodes {
-		curClasses, attr := getClassesAndAttr(n, true)
-		for _, newClass := range tcls {
-			if strings.Index(curClasses, " "+newClass+" ") == -1 {
-				curClasses += newClass + " "
-			}
+		if n.Type != html.ElementNode {
+			continue
 		}
+		s.document.changeAttrs(n, func() {
+			classes, attr := getClassesAndAttr(n, true)
+			for _, tcl := range tcls {
+				if !isClassInSlice(classes, tcl) {
+					classes = append(classes, tcl)
+				}
+			}
 
-		setClasses(n, attr, curClasses)
+			setClasses(n, attr, classes)
+		})
 	}
 
 	return s
 }
 
+// Classes returns the classes of the first element in the Selection, in the
+// order of the class attribute, without duplicates. It returns nil if the
+// Selection is empty or the element has no class.
+func (s *Selection) Classes() []string {
+	if len(s.Nodes) == 0 {
+		return nil
+	}
+	classes, _ := getClassesAndAttr(s.Nodes[0], false)
+	return classes
+}
+
 // HasClass determines whether any of the matched elements are assigned the
 // given class.
 func (s *Selection) HasClass(class string) bool {
-	class = " " + class + " "
 	for _, n := range s.Nodes {
-		classes, _ := getClassesAndAttr(n, false)
-		if strings.Index(classes, class) > -1 {
+		if nodeHasClass(n, class) {
 			return true
 		}
 	}
 	return false
 }
 
+// HasAllClasses determines whether any of the matched elements are assigned
+// all of the given classes. Multiple class names can be specified, separated
+// by a space or via multiple arguments. It returns false if no class name is
+// provided.
+func (s *Selection) HasAllClasses(class ...string) bool {
+	tcls := getClassesSlice(class...)
+	if len(tcls) == 0 {
+		return false
+	}
+
+	for _, n := range s.Nodes {
+		all := true
+		for _, tcl := range tcls {
+			if !nodeHasClass(n, tcl) {
+				all = false
+				break
+			}
+		}
+		if all {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAnyClass determines whether any of the matched elements are assigned
+// at least one of the given classes. Multiple class names can be specified,
+// separated by a space or via multiple arguments.
+func (s *Selection) HasAnyClass(class ...string) bool {
+	tcls := getClassesSlice(class...)
+	for _, n := range s.Nodes {
+		for _, tcl := range tcls {
+			if nodeHasClass(n, tcl) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // RemoveClass removes the given class(es) from each element in the set of matched elements.
 // Multiple class names can be specified, separated by a space or via multiple arguments.
 // If no class name is provided, all classes are removed.
 func (s *Selection) RemoveClass(class ...string) *Selection {
-	var rclasses []string
+	rclasses := getClassesSlice(class...)
+	remove := len(rclasses) == 0
 
-	classStr := strings.TrimSpace(strings.Join(class, " "))
-	remove := classStr == ""
+	for _, n := range s.Nodes {
+		s.document.changeAttrs(n, func() {
+			if remove {
+				removeAttr(n, "class")
+				return
+			}
+			classes, attr := getClassesAndAttr(n, false)
+			if attr == nil {
+				return
+			}
+			result := classes[:0]
+			for _, cl := range classes {
+				if !isClassInSlice(rclasses, cl) {
+					result = append(result, cl)
+				}
+			}
+
+			setClasses(n, attr, result)
+		})
+	}
+
+	return s
+}
 
-	if !remove {
-		rclasses = getClassesSlice(classStr)
+// ReplaceClass replaces the given class with the new class on each element
+// in the set of matched elements that is assigned the class, like the DOM's
+// classList.replace. The new class takes the position of the old one, and
+// any other occurrence of the new class is removed. Elements that are not
+// assigned the old class are left untouched, as are all elements if either
+// class name is empty or contains whitespace.
+func (s *Selection) ReplaceClass(oldClass, newClass string) *Selection {
+	if !isClassToken(oldClass) || !isClassToken(newClass) {
+		return s
 	}
 
 	for _, n := range s.Nodes {
-		if remove {
-			removeAttr(n, "class")
-		} else {
-			classes, attr := getClassesAndAttr(n, true)
-			for _, rcl := range rclasses {
-				classes = strings.Replace(classes, " "+rcl+" ", " ", -1)
+		classes, attr := getClassesAndAttr(n, false)
+		if !isClassInSlice(classes, oldClass) {
+			continue
+		}
+
+		s.document.changeAttrs(n, func() {
+			result := classes[:0]
+			placed := false
+			for _, cl := range classes {
+				if cl == oldClass || cl == newClass {
+					if placed {
+						continue
+					}
+					cl, placed = newClass, true
+				}
+				result = append(result, cl)
 			}
 
-			setClasses(n, attr, classes)
-		}
+			setClasses(n, attr, result)
+		})
 	}
 
 	return s
@@ -154,30 +363,320 @@ func (s *Selection) RemoveClass(class ...string) *Selection {
 // ToggleClass adds or removes the given class(es) for each element in the set of matched elements.
 // Multiple class names can be specified, separated by a space or via multiple arguments.
 func (s *Selection) ToggleClass(class ...string) *Selection {
-	classStr := strings.TrimSpace(strings.Join(class, " "))
+	tcls := getClassesSlice(class...)
+	if len(tcls) == 0 {
+		return s
+	}
+
+	for _, n := range s.Nodes {
+		if n.Type != html.ElementNode {
+			continue
+		}
+		s.document.changeAttrs(n, func() {
+			classes, attr := getClassesAndAttr(n, true)
+			for _, tcl := range tcls {
+				if i := indexOfClass(classes, tcl); i > -1 {
+					classes = append(classes[:i], classes[i+1:]...)
+				} else {
+					classes = append(classes, tcl)
+				}
+			}
+
+			setClasses(n, attr, classes)
+		})
+	}
 
-	if classStr == "" {
+	return s
+}
+
+// ToggleClassState adds the given class(es) to each element in the set of
+// matched elements if on is true, and removes them otherwise, like the DOM's
+// classList.toggle with the force argument. Multiple class names can be
+// specified, separated by a space. Unlike RemoveClass, nothing is removed if
+// no class name is provided.
+func (s *Selection) ToggleClassState(class string, on bool) *Selection {
+	if on {
+		return s.AddClass(class)
+	}
+	if len(getClassesSlice(class)) == 0 {
 		return s
 	}
+	return s.RemoveClass(class)
+}
 
-	tcls := getClassesSlice(classStr)
+// Css gets the value of the given property in the inline style (the style
+// attribute) of the first element in the Selection, without the !important
+// flag. The property name is case-insensitive, except for custom properties
+// (--name). It returns an empty string if the property is not set.
+func (s *Selection) Css(prop string) string {
+	if len(s.Nodes) == 0 {
+		return ""
+	}
+
+	decls, _ := getStyleAndAttr(s.Nodes[0], false)
+	if i := findStyleDecl(decls, prop); i > -1 {
+		return decls[i].value
+	}
+	return ""
+}
+
+// SetCss sets the given property in the inline style of each element in the
+// set of matched elements. The value may end with !important. An existing
+// declaration of the property is updated in place, keeping the order of the
+// other declarations, otherwise the declaration is added at the end. An
+// empty value removes the property, like jQuery's css(). As in browsers, the
+// property is left unchanged if its name is not a valid identifier or its
+// value is not a single valid value, such as "red; top: 0", a value with
+// braces, unclosed quotes or parentheses, or a misplaced !important. The
+// comments and invalid declarations of the style are preserved.
+func (s *Selection) SetCss(prop, val string) *Selection {
+	return s.SetCssMap(map[string]string{prop: val})
+}
+
+// SetCssMap sets the given properties in the inline style of each element in
+// the set of matched elements, following the same rules as SetCss. The new
+// declarations are added in the order of the property names.
+func (s *Selection) SetCssMap(props map[string]string) *Selection {
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	valid := names[:0]
+	for _, name := range names {
+		if isValidStyleName(name) && isValidStyleValue(props[name]) {
+			valid = append(valid, name)
+		}
+	}
+	if len(valid) == 0 {
+		return s
+	}
 
 	for _, n := range s.Nodes {
-		classes, attr := getClassesAndAttr(n, true)
-		for _, tcl := range tcls {
-			if strings.Index(classes, " "+tcl+" ") != -1 {
-				classes = strings.Replace(classes, " "+tcl+" ", " ", -1)
-			} else {
-				classes += tcl + " "
+		s.document.changeAttrs(n, func() {
+			decls, attr := getStyleAndAttr(n, true)
+			for _, name := range valid {
+				decls = setStyleDecl(decls, name, props[name])
 			}
+			setStyle(n, attr, decls)
+		})
+	}
+
+	return s
+}
+
+// RemoveCss removes the given properties from the inline style of each
+// element in the set of matched elements. The style attribute is removed
+// if nothing is left, not even a comment.
+func (s *Selection) RemoveCss(prop ...string) *Selection {
+	for _, n := range s.Nodes {
+		decls, attr := getStyleAndAttr(n, false)
+		if attr == nil {
+			continue
 		}
+		s.document.changeAttrs(n, func() {
+			for _, name := range prop {
+				decls = setStyleDecl(decls, name, "")
+			}
+			setStyle(n, attr, decls)
+		})
+	}
 
-		setClasses(n, attr, classes)
+	return s
+}
+
+// Data gets the value of the data-* attribute for the given key on the first
+// element in the Selection, like jQuery's data() and the DOM's dataset. The
+// key can be given in camelCase (as in the dataset, e.g. "fooBar") or in
+// kebab-case (as in the attribute name, e.g. "foo-bar"), both refer to the
+// data-foo-bar attribute.
+func (s *Selection) Data(key string) (val string, exists bool) {
+	if len(s.Nodes) == 0 {
+		return
+	}
+	return getAttributeValue(dataKeyToAttr(key), s.Nodes[0])
+}
+
+// SetData sets the data-* attribute for the given key on each element in the
+// set of matched elements. The key follows the same rules as in Data.
+func (s *Selection) SetData(key, val string) *Selection {
+	return s.SetAttr(dataKeyToAttr(key), val)
+}
+
+// DataMap returns the data-* attributes of the first element in the
+// Selection, keyed by their camelCase names, like the DOM's dataset. It
+// returns nil if the Selection is empty.
+func (s *Selection) DataMap() map[string]string {
+	if len(s.Nodes) == 0 {
+		return nil
+	}
+
+	m := make(map[string]string)
+	for _, a := range s.Nodes[0].Attr {
+		if a.Namespace == "" && strings.HasPrefix(a.Key, "data-") {
+			// The first attribute wins, as in the dataset
+			k := dataAttrToKey(a.Key)
+			if _, ok := m[k]; !ok {
+				m[k] = a.Val
+			}
+		}
+	}
+	return m
+}
+
+// DataJSON decodes the JSON payload of the data-* attribute for the given key
+// on the first element in the Selection into v, using json.Unmarshal. The
+// key follows the same rules as in Data. It returns ErrDataNotFound if there
+// is no such attribute.
+func (s *Selection) DataJSON(key string, v interface{}) error {
+	val, ok := s.Data(key)
+	if !ok {
+		return ErrDataNotFound
+	}
+	return json.Unmarshal([]byte(val), v)
+}
+
+// Prop gets the value of the given boolean property for the first element in
+// the Selection. For boolean attributes (checked, selected, disabled, hidden,
+// readonly, required, multiple, etc.), the presence of the attribute is the
+// value, regardless of its content, so <input checked> and
+// <input checked="false"> are both checked. Unlike Attr, which returns an
+// empty string in both cases, Prop returns true if the attribute is present.
+// It returns false if the Selection is empty, or if name is not one of the
+// HTML boolean attributes.
+func (s *Selection) Prop(name string) bool {
+	name = strings.ToLower(name)
+	if len(s.Nodes) == 0 || !isBooleanAttr(name) {
+		return false
+	}
+	return getAttributePtr(name, s.Nodes[0]) != nil
+}
+
+// SetProp sets the given boolean property on each element in the set of
+// matched elements, by adding the attribute (with an empty value) if val is
+// true, or removing it otherwise.
+//
+// As in the DOM, setting checked on a radio button unchecks the other radio
+// buttons of the same group, and setting selected on an option of a select
+// element that does not allow multiple selections unselects the other options.
+// If name is not one of the HTML boolean attributes, the elements are left
+// untouched.
+func (s *Selection) SetProp(name string, val bool) *Selection {
+	name = strings.ToLower(name)
+	if !isBooleanAttr(name) {
+		return s
+	}
+
+	for _, n := range s.Nodes {
+		if n.Type != html.ElementNode {
+			continue
+		}
+		s.document.changeAttrs(n, func() {
+			if !val {
+				removeAttr(n, name)
+			} else if getAttributePtr(name, n) == nil {
+				n.Attr = append(n.Attr, html.Attribute{Key: name})
+			}
+		})
+		if !val {
+			continue
+		}
+		switch {
+		case name == "checked" && isRadio(n):
+			for _, r := range radioGroup(n) {
+				if r != n {
+					s.document.changeAttrs(r, func() {
+						removeAttr(r, "checked")
+					})
+				}
+			}
+		case name == "selected" && n.DataAtom == atom.Option:
+			if sel := getParentSelect(n); sel != nil && getAttributePtr("multiple", sel) == nil {
+				for _, o := range getOptions(sel) {
+					if o != n {
+						s.document.changeAttrs(o, func() {
+							removeAttr(o, "selected")
+						})
+					}
+				}
+			}
+		}
 	}
 
 	return s
 }
 
+// TagName gets the tag name of the first element in the Selection, as
+// reflected by the DOM's tagName property: uppercase for HTML elements, and
+// as-is for SVG and MathML elements. It returns an empty string if the
+// Selection is empty or the first node is not an element.
+func (s *Selection) TagName() string {
+	if len(s.Nodes) == 0 || s.Nodes[0].Type != html.ElementNode {
+		return ""
+	}
+	if s.Nodes[0].Namespace != "" {
+		return s.Nodes[0].Data
+	}
+	return strings.ToUpper(s.Nodes[0].Data)
+}
+
+// Id gets the id of the first element in the Selection, as reflected by the
+// DOM's id property, or an empty string if it has none.
+func (s *Selection) Id() string {
+	return s.AttrOr("id", "")
+}
+
+// Href gets the href attribute of the first element in the Selection,
+// resolved as an absolute URL like the DOM's href property. The URL is
+// resolved against the document's <base href> if there is one, and the
+// Document's Url. It returns the attribute's value unchanged if it cannot
+// be resolved to an absolute URL, and an empty string if the attribute
+// does not exist.
+func (s *Selection) Href() string {
+	val, ok := s.Attr("href")
+	if !ok {
+		return ""
+	}
+	return resolveURL(s.document, strings.TrimSpace(val))
+}
+
+// Val gets the current value of the first element in the Selection, like
+// jQuery's val() and the DOM's value property:
+//
+// 1) For input elements, the value attribute. Checkboxes and radio buttons
+// without a value attribute have the value "on".
+//
+// 2) For textarea elements, the text content.
+//
+// 3) For option elements, the value attribute, or the text content with
+// whitespace stripped and collapsed if there is no value attribute.
+//
+// 4) For select elements, the value of the selected option (see
+// SelectedIndex), or an empty string if no option is selected.
+//
+// 5) For other elements, the value attribute.
+func (s *Selection) Val() string {
+	if len(s.Nodes) == 0 {
+		return ""
+	}
+	return getNodeValue(s.Nodes[0])
+}
+
+// SelectedIndex gets the index of the selected option of the first element
+// in the Selection, if it is a select element, like the DOM's selectedIndex
+// property. It is the index of the last option with the selected attribute,
+// or of the first one if the select element allows multiple selections, as
+// in the DOM. If there is none, it is 0 if the select element has options
+// and does not allow multiple selections (since the browser selects the
+// first option), and -1 otherwise. It is also -1 if the first element is not
+// a select element.
+func (s *Selection) SelectedIndex() int {
+	if len(s.Nodes) == 0 || s.Nodes[0].DataAtom != atom.Select {
+		return -1
+	}
+	return getSelectedIndex(s.Nodes[0])
+}
+
 // Get the specified node's text content.
 func getNodeText(node *html.Node) string {
 	if node.Type == html.TextNode {
@@ -200,13 +699,48 @@ func getAttributePtr(attrName string, n *html.Node) *html.Attribute {
 	}
 
 	for i, a := range n.Attr {
-		if a.Key == attrName {
+		if attrNameIs(a, attrName) {
 			return &n.Attr[i]
 		}
 	}
 	return nil
 }
 
+// Checks if the qualified name of the attribute is the specified name,
+// without allocating.
+func attrNameIs(a html.Attribute, attrName string) bool {
+	if a.Namespace == "" {
+		return a.Key == attrName
+	}
+	return len(attrName) == len(a.Namespace)+1+len(a.Key) &&
+		strings.HasPrefix(attrName, a.Namespace) &&
+		attrName[len(a.Namespace)] == ':' &&
+		strings.HasSuffix(attrName, a.Key)
+}
+
+// Returns the qualified name of the attribute, e.g. xlink:href.
+func qualifiedAttrName(a html.Attribute) string {
+	if a.Namespace == "" {
+		return a.Key
+	}
+	return a.Namespace + ":" + a.Key
+}
+
+// Splits a qualified attribute name in its namespace and local name, the
+// same way the parser does: only the xlink, xml and xmlns attributes of
+// SVG and MathML elements (foreign content) get a namespace.
+func splitAttrName(n *html.Node, attrName string) (ns, key string) {
+	if n.Namespace != "" {
+		switch attrName {
+		case "xlink:actuate", "xlink:arcrole", "xlink:href", "xlink:role", "xlink:show",
+			"xlink:title", "xlink:type", "xml:lang", "xml:space", "xmlns:xlink":
+			i := strings.Index(attrName, ":")
+			return attrName[:i], attrName[i+1:]
+		}
+	}
+	return "", attrName
+}
+
 // Private function to get the specified attribute's value from a node.
 func getAttributeValue(attrName string, n *html.Node) (val string, exists bool) {
 	if a := getAttributePtr(attrName, n); a != nil {
@@ -216,8 +750,8 @@ func getAttributeValue(attrName string, n *html.Node) (val string, exists bool)
 	return
 }
 
-// Get and normalize the "class" attribute from the node.
-func getClassesAndAttr(n *html.Node, create bool) (classes string, attr *html.Attribute) {
+// Get the classes and the "class" attribute from the node.
+func getClassesAndAttr(n *html.Node, create bool) (classes []string, attr *html.Attribute) {
 	// Applies only to element nodes
 	if n.Type == html.ElementNode {
 		attr = getAttributePtr("class", n)
@@ -230,22 +764,111 @@ func getClassesAndAttr(n *html.Node, create bool) (classes string, attr *html.At
 		}
 	}
 
-	if attr == nil {
-		classes = " "
-	} else {
-		classes = rxClassTrim.ReplaceAllString(" "+attr.Val+" ", " ")
+	if attr != nil {
+		classes = getClassesSlice(attr.Val)
 	}
 
 	return
 }
 
-func getClassesSlice(classes string) []string {
-	return strings.Split(rxClassTrim.ReplaceAllString(" "+classes+" ", " "), " ")
+// Split the class names on ASCII whitespace, as per the DOMTokenList rules,
+// removing duplicates.
+func getClassesSlice(classes ...string) (result []string) {
+	for _, cls := range classes {
+		for start, i := -1, 0; i <= len(cls); i++ {
+			if i < len(cls) && !isASCIIWhitespace(cls[i]) {
+				if start < 0 {
+					start = i
+				}
+				continue
+			}
+			if start >= 0 {
+				if cl := cls[start:i]; !isClassInSlice(result, cl) {
+					result = append(result, cl)
+				}
+				start = -1
+			}
+		}
+	}
+	return result
+}
+
+// Checks if the node is assigned the class, without allocating.
+func nodeHasClass(n *html.Node, class string) bool {
+	if class == "" || n.Type != html.ElementNode {
+		return false
+	}
+	attr := getAttributePtr("class", n)
+	if attr == nil {
+		return false
+	}
+
+	val := attr.Val
+	for from := 0; from < len(val); {
+		i := strings.Index(val[from:], class)
+		if i < 0 {
+			return false
+		}
+		start := from + i
+		end := start + len(class)
+		if (start == 0 || isASCIIWhitespace(val[start-1])) && (end == len(val) || isASCIIWhitespace(val[end])) {
+			return true
+		}
+		from = start + 1
+	}
+	return false
+}
+
+// Checks if the class is a single, non-empty class name.
+func isClassToken(class string) bool {
+	if class == "" {
+		return false
+	}
+	for i := 0; i < len(class); i++ {
+		if isASCIIWhitespace(class[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func indexOfClass(classes []string, class string) int {
+	for i, cl := range classes {
+		if cl == class {
+			return i
+		}
+	}
+	return -1
+}
+
+func isClassInSlice(classes []string, class string) bool {
+	return indexOfClass(classes, class) > -1
+}
+
+// ASCII whitespace, as defined by the HTML spec.
+func isASCIIWhitespace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\f' || c == '\r'
+}
+
+// Removes the attributes for which the function returns true, preserving
+// the order of the others.
+func removeAttrsMatching(n *html.Node, f func(string) bool) {
+	attrs := n.Attr[:0]
+	for _, a := range n.Attr {
+		if !f(qualifiedAttrName(a)) {
+			attrs = append(attrs, a)
+		}
+	}
+	// Clear the unused tail so the removed values can be collected
+	for i := len(attrs); i < len(n.Attr); i++ {
+		n.Attr[i] = html.Attribute{}
+	}
+	n.Attr = attrs
 }
 
 func removeAttr(n *html.Node, attrName string) {
 	for i, a := range n.Attr {
-		if a.Key == attrName {
+		if attrNameIs(a, attrName) {
 			n.Attr[i], n.Attr[len(n.Attr)-1], n.Attr =
 				n.Attr[len(n.Attr)-1], html.Attribute{}, n.Attr[:len(n.Attr)-1]
 			return
@@ -253,12 +876,523 @@ func removeAttr(n *html.Node, attrName string) {
 	}
 }
 
-func setClasses(n *html.Node, attr *html.Attribute, classes string) {
-	classes = strings.TrimSpace(classes)
-	if classes == "" {
+func setClasses(n *html.Node, attr *html.Attribute, classes []string) {
+	if len(classes) == 0 {
 		removeAttr(n, "class")
 		return
 	}
 
-	attr.Val = classes
+	attr.Val = strings.Join(classes, " ")
+}
+
+// A CSS declaration of an inline style. The comments and the invalid
+// declarations are kept verbatim in raw, with an empty name, so that
+// updating the style does not lose them.
+type styleDecl struct {
+	name      string
+	value     string
+	important bool
+	raw       string
+}
+
+func (d styleDecl) String() string {
+	if d.raw != "" {
+		return d.raw
+	}
+	if d.important {
+		return d.name + ": " + d.value + " !important"
+	}
+	return d.name + ": " + d.value
+}
+
+// Get and parse the "style" attribute from the node, including its comments
+// and invalid declarations.
+func getStyleAndAttr(n *html.Node, create bool) (decls []styleDecl, attr *html.Attribute) {
+	// Applies only to element nodes
+	if n.Type == html.ElementNode {
+		attr = getAttributePtr("style", n)
+		if attr == nil && create {
+			n.Attr = append(n.Attr, html.Attribute{
+				Key: "style",
+				Val: "",
+			})
+			attr = &n.Attr[len(n.Attr)-1]
+		}
+	}
+
+	if attr != nil {
+		decls = parseStyleItems(attr.Val)
+	}
+	return
+}
+
+func setStyle(n *html.Node, attr *html.Attribute, decls []styleDecl) {
+	if attr == nil {
+		return
+	}
+	if len(decls) == 0 {
+		removeAttr(n, "style")
+		return
+	}
+
+	var buf bytes.Buffer
+	for i, d := range decls {
+		if i > 0 {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(d.String())
+		if !strings.HasPrefix(d.raw, "/*") {
+			buf.WriteByte(';')
+		}
+	}
+	attr.Val = buf.String()
+}
+
+// Parse the declarations of a style attribute. Declarations without a colon,
+// a property name or a value are invalid and dropped, as browsers do.
+func parseStyle(style string) (decls []styleDecl) {
+	for _, d := range parseStyleItems(style) {
+		if d.raw == "" {
+			decls = append(decls, d)
+		}
+	}
+	return
+}
+
+// Parse the declarations of a style attribute, keeping the comments and the
+// invalid declarations as raw items, in source order.
+func parseStyleItems(style string) (decls []styleDecl) {
+	for _, chunk := range splitStyle(style) {
+		text, before, after := splitStyleComments(chunk)
+		for _, c := range before {
+			decls = append(decls, styleDecl{raw: c})
+		}
+		if text = strings.TrimSpace(text); text != "" {
+			if d, ok := parseStyleDecl(text); ok {
+				decls = append(decls, d)
+			} else {
+				decls = append(decls, styleDecl{raw: text})
+			}
+		}
+		for _, c := range after {
+			decls = append(decls, styleDecl{raw: c})
+		}
+	}
+	return
+}
+
+// Parse a declaration, without its comments and semicolon.
+func parseStyleDecl(text string) (styleDecl, bool) {
+	i := strings.Index(text, ":")
+	if i < 0 {
+		return styleDecl{}, false
+	}
+	name := normalizeStyleName(text[:i])
+	if name == "" {
+		return styleDecl{}, false
+	}
+	value, important := splitImportant(strings.TrimSpace(text[i+1:]))
+	if value == "" {
+		return styleDecl{}, false
+	}
+	return styleDecl{name: name, value: value, important: important}, true
+}
+
+// Split the comments out of a part of a style attribute. The comments before
+// the declaration, or before its colon, are returned in before, the others
+// in after. An unclosed comment runs to the end of the part.
+func splitStyleComments(part string) (text string, before, after []string) {
+	var (
+		buf   bytes.Buffer
+		quote byte
+		colon bool
+	)
+
+	for i := 0; i < len(part); i++ {
+		c := part[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && i+1 < len(part) {
+				buf.WriteByte(c)
+				i++
+				c = part[i]
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '/' && i+1 < len(part) && part[i+1] == '*':
+			end := strings.Index(part[i+2:], "*/")
+			if end < 0 {
+				end = len(part)
+			} else {
+				end += i + 4
+			}
+			if colon || strings.TrimSpace(buf.String()) != "" && strings.TrimSpace(part[end:]) == "" {
+				after = append(after, part[i:end])
+			} else {
+				before = append(before, part[i:end])
+			}
+			// A comment separates tokens, like whitespace
+			buf.WriteByte(' ')
+			i = end - 1
+			continue
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ':':
+			colon = true
+		}
+		buf.WriteByte(c)
+	}
+	return buf.String(), before, after
+}
+
+// Split a style attribute on the semicolons that are not within quotes,
+// comments or parentheses (e.g. in url(...)).
+func splitStyle(style string) (parts []string) {
+	var (
+		buf   bytes.Buffer
+		quote byte
+		depth int
+	)
+
+	for i := 0; i < len(style); i++ {
+		c := style[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && i+1 < len(style) {
+				buf.WriteByte(c)
+				i++
+				c = style[i]
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '/' && i+1 < len(style) && style[i+1] == '*':
+			end := strings.Index(style[i+2:], "*/")
+			if end < 0 {
+				end = len(style)
+			} else {
+				end += i + 4
+			}
+			buf.WriteString(style[i:end])
+			i = end - 1
+			continue
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')' && depth > 0:
+			depth--
+		case c == ';' && depth == 0:
+			parts = append(parts, buf.String())
+			buf.Reset()
+			continue
+		}
+		buf.WriteByte(c)
+	}
+	return append(parts, buf.String())
+}
+
+// Checks if the property name is a valid identifier.
+func isValidStyleName(name string) bool {
+	name = normalizeStyleName(name)
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if !isSelectorNameChar(name[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Checks that the value, which may end with !important, can't change other
+// declarations: its strings, comments, parentheses and brackets are closed,
+// and it has no braces, no semicolon outside of parentheses, and no other
+// "!".
+func isValidStyleValue(val string) bool {
+	value, _ := splitImportant(strings.TrimSpace(val))
+	var stack []byte
+	var quote byte
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '/' && i+1 < len(value) && value[i+1] == '*':
+			end := strings.Index(value[i+2:], "*/")
+			if end < 0 {
+				return false
+			}
+			i += end + 3
+		case c == '(':
+			stack = append(stack, ')')
+		case c == '[':
+			stack = append(stack, ']')
+		case c == ')' || c == ']':
+			if len(stack) == 0 || stack[len(stack)-1] != c {
+				return false
+			}
+			stack = stack[:len(stack)-1]
+		case c == '{' || c == '}' || c == '!':
+			return false
+		case c == ';' && len(stack) == 0:
+			return false
+		}
+	}
+	return quote == 0 && len(stack) == 0
+}
+
+// Split the !important flag from a declaration's value.
+func splitImportant(value string) (string, bool) {
+	i := strings.LastIndex(value, "!")
+	if i < 0 || !strings.EqualFold(strings.TrimSpace(value[i+1:]), "important") {
+		return value, false
+	}
+	return strings.TrimSpace(value[:i]), true
+}
+
+// Property names are case-insensitive, except for custom properties.
+func normalizeStyleName(name string) string {
+	name = strings.TrimSpace(name)
+	if strings.HasPrefix(name, "--") {
+		return name
+	}
+	return strings.ToLower(name)
+}
+
+// Returns the index of the declaration that applies for the property, or -1.
+// If the property is declared more than once, the last !important
+// declaration wins, otherwise the last one.
+func findStyleDecl(decls []styleDecl, name string) int {
+	name = normalizeStyleName(name)
+	found := -1
+	for i, d := range decls {
+		if d.raw == "" && d.name == name && (found < 0 || d.important || !decls[found].important) {
+			found = i
+		}
+	}
+	return found
+}
+
+// Set the value of a property in the declarations, replacing the first
+// declaration of that property and removing the others, or appending a new
+// one. An empty value removes all declarations of the property.
+func setStyleDecl(decls []styleDecl, name, val string) []styleDecl {
+	name = normalizeStyleName(name)
+	if name == "" {
+		return decls
+	}
+	value, important := splitImportant(strings.TrimSpace(val))
+
+	result := decls[:0]
+	set := value == ""
+	for _, d := range decls {
+		if d.name == name {
+			if set {
+				continue
+			}
+			d.value, d.important = value, important
+			set = true
+		}
+		result = append(result, d)
+	}
+	if !set {
+		result = append(result, styleDecl{name: name, value: value, important: important})
+	}
+	return result
+}
+
+// Convert a dataset key to its data-* attribute name. A camelCase key gets a
+// dash inserted before each ASCII uppercase letter, which is lowercased, as
+// per the HTML spec. A key that is already in kebab-case is used as-is.
+func dataKeyToAttr(key string) string {
+	var buf bytes.Buffer
+
+	buf.WriteString("data-")
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if 'A' <= c && c <= 'Z' {
+			buf.WriteByte('-')
+			c += 'a' - 'A'
+		}
+		buf.WriteByte(c)
+	}
+	return buf.String()
+}
+
+// Convert a data-* attribute name to its dataset key. The prefix is removed
+// and each dash followed by an ASCII lowercase letter is removed, with the
+// letter uppercased, as per the HTML spec.
+func dataAttrToKey(attr string) string {
+	var buf bytes.Buffer
+
+	attr = strings.TrimPrefix(attr, "data-")
+	for i := 0; i < len(attr); i++ {
+		c := attr[i]
+		if c == '-' && i+1 < len(attr) && 'a' <= attr[i+1] && attr[i+1] <= 'z' {
+			i++
+			c = attr[i] - ('a' - 'A')
+		}
+		buf.WriteByte(c)
+	}
+	return buf.String()
+}
+
+// Checks if the attribute is one of the HTML boolean attributes.
+func isBooleanAttr(name string) bool {
+	switch name {
+	case "allowfullscreen", "async", "autofocus", "autoplay", "checked", "controls",
+		"default", "defer", "disabled", "formnovalidate", "hidden", "inert", "ismap",
+		"itemscope", "loop", "multiple", "muted", "nomodule", "novalidate", "open",
+		"playsinline", "readonly", "required", "reversed", "selected":
+		return true
+	}
+	return false
+}
+
+func isRadio(n *html.Node) bool {
+	t, _ := getAttributeValue("type", n)
+	return n.DataAtom == atom.Input && strings.EqualFold(t, "radio")
+}
+
+// Gets the radio buttons in the same group as n: same name, within the same
+// form, or outside of any form if n is not in a form.
+func radioGroup(n *html.Node) (result []*html.Node) {
+	name, ok := getAttributeValue("name", n)
+	if !ok || name == "" {
+		return nil
+	}
+
+	// Search from the form, or from the top of the tree
+	form, root := n, n
+	for ; form != nil && form.DataAtom != atom.Form; form = form.Parent {
+		root = form
+	}
+	if form != nil {
+		root = form
+	}
+
+	for c := nextInTree(root, root); c != nil; c = nextInTree(c, root) {
+		if isRadio(c) && getClosestForm(c) == form {
+			if cname, _ := getAttributeValue("name", c); cname == name {
+				result = append(result, c)
+			}
+		}
+	}
+	return result
+}
+
+func getClosestForm(n *html.Node) *html.Node {
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p.DataAtom == atom.Form {
+			return p
+		}
+	}
+	return nil
+}
+
+func getParentSelect(n *html.Node) *html.Node {
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p.DataAtom == atom.Select {
+			return p
+		}
+	}
+	return nil
+}
+
+// Gets the option elements of a select element, including those in optgroups.
+func getOptions(sel *html.Node) (result []*html.Node) {
+	for c := nextInTree(sel, sel); c != nil; c = nextInTree(c, sel) {
+		if c.DataAtom == atom.Option {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+func getSelectedIndex(sel *html.Node) int {
+	opts := getOptions(sel)
+	multiple := getAttributePtr("multiple", sel) != nil
+	selected := -1
+	for i, o := range opts {
+		if getAttributePtr("selected", o) != nil {
+			selected = i
+			if multiple {
+				break
+			}
+		}
+	}
+	if selected < 0 && len(opts) > 0 && !multiple {
+		return 0
+	}
+	return selected
+}
+
+// Get the value of a node, as described on Selection.Val.
+func getNodeValue(n *html.Node) string {
+	switch n.DataAtom {
+	case atom.Input:
+		if val, ok := getAttributeValue("value", n); ok {
+			return val
+		}
+		t, _ := getAttributeValue("type", n)
+		if strings.EqualFold(t, "checkbox") || strings.EqualFold(t, "radio") {
+			return "on"
+		}
+		return ""
+	case atom.Textarea:
+		return getNodeText(n)
+	case atom.Option:
+		if val, ok := getAttributeValue("value", n); ok {
+			return val
+		}
+		return strings.Join(strings.Fields(getNodeText(n)), " ")
+	case atom.Select:
+		if i := getSelectedIndex(n); i > -1 {
+			return getNodeValue(getOptions(n)[i])
+		}
+		return ""
+	}
+	val, _ := getAttributeValue("value", n)
+	return val
+}
+
+// Resolve the URL against the document's base URL, if possible.
+func resolveURL(doc *Document, val string) string {
+	u, e := url.Parse(val)
+	if e != nil {
+		return val
+	}
+	if base := getBaseURL(doc); base != nil {
+		u = base.ResolveReference(u)
+	}
+	if !u.IsAbs() {
+		return val
+	}
+	return u.String()
+}
+
+// Get the document's base URL, from the first <base href> element resolved
+// against the Document's Url, or the Document's Url.
+func getBaseURL(doc *Document) *url.URL {
+	if doc == nil {
+		return nil
+	}
+	base := doc.Url
+	if nodes := findNWithMatcher([]*html.Node{doc.rootNode}, baseHrefMatcher, 1); len(nodes) > 0 {
+		href, _ := getAttributeValue("href", nodes[0])
+		if u, e := url.Parse(strings.TrimSpace(href)); e == nil {
+			if base != nil {
+				u = base.ResolveReference(u)
+			}
+			base = u
+		}
+	}
+	return base
 }
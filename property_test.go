@@ -1,6 +1,7 @@
 package goquery
 
 import (
+	"net/url"
 	"regexp"
 	"strings"
 	"testing"
@@ -237,3 +238,672 @@ func TestToggleClass(t *testing.T) {
 		t.Errorf("Expected #nf1 to have no classes, have %q", a)
 	}
 }
+
+func TestHasClassWhitespace(t *testing.T) {
+	sel := Doc2Clone().Find("#main")
+	sel.SetAttr("class", "xab\tab\fcd\nef")
+
+	for _, cl := range []string{"ab", "cd", "ef", "xab"} {
+		if !sel.HasClass(cl) {
+			t.Errorf("Expected #main to have class %s.", cl)
+		}
+	}
+	for _, cl := range []string{"a", "b", "x", "", "ab cd"} {
+		if sel.HasClass(cl) {
+			t.Errorf("Expected #main to not have class %q.", cl)
+		}
+	}
+}
+
+func TestClasses(t *testing.T) {
+	sel := Doc2Clone().Find("#main")
+	sel.SetAttr("class", " b\ta\r\nb  c\f")
+
+	classes := sel.Classes()
+	if strings.Join(classes, ",") != "b,a,c" {
+		t.Errorf("Expected classes b,a,c, got %v.", classes)
+	}
+	if classes := Doc2().Find("#main").Classes(); classes != nil {
+		t.Errorf("Expected no classes, got %v.", classes)
+	}
+	if classes := Doc().Find("zzzz").Classes(); classes != nil {
+		t.Errorf("Expected no classes on empty selection, got %v.", classes)
+	}
+}
+
+func TestAddClassDedupe(t *testing.T) {
+	sel := Doc2Clone().Find("#main")
+	sel.SetAttr("class", "a\tb a")
+	sel.AddClass("c a", "c")
+
+	if a, _ := sel.Attr("class"); a != "a b c" {
+		t.Errorf("Expected class attribute to be normalized to %q, got %q.", "a b c", a)
+	}
+}
+
+func TestReplaceClass(t *testing.T) {
+	sel := Doc2Clone().Find("#main")
+	sel.SetAttr("class", "a b c b")
+
+	sel.ReplaceClass("b", "x")
+	if a, _ := sel.Attr("class"); a != "a x c" {
+		t.Errorf("Expected class %q, got %q.", "a x c", a)
+	}
+
+	// New class already present
+	sel.ReplaceClass("c", "a")
+	if a, _ := sel.Attr("class"); a != "a x" {
+		t.Errorf("Expected class %q, got %q.", "a x", a)
+	}
+
+	// No-ops
+	sel.ReplaceClass("zzz", "y").ReplaceClass("a", "").ReplaceClass("a", "y z").ReplaceClass(" a", "y")
+	if a, _ := sel.Attr("class"); a != "a x" {
+		t.Errorf("Expected class %q, got %q.", "a x", a)
+	}
+}
+
+func TestToggleClassState(t *testing.T) {
+	sel := Doc2Clone().Find("#nf1")
+
+	sel.ToggleClassState("one", true)
+	if !sel.HasClass("one") {
+		t.Error("Expected #nf1 to keep class one")
+	}
+	sel.ToggleClassState("one row", false)
+	if sel.HasClass("one") || sel.HasClass("row") || !sel.HasClass("even") {
+		t.Error("Expected #nf1 to have only class even")
+	}
+	sel.ToggleClassState(" ", false)
+	if !sel.HasClass("even") {
+		t.Error("Expected #nf1 to keep its classes on empty class name")
+	}
+	sel.ToggleClassState("new", true)
+	if !sel.HasAllClasses("even new") {
+		t.Error("Expected #nf1 to have classes even and new")
+	}
+}
+
+func TestHasAllClasses(t *testing.T) {
+	sel := Doc2().Find("#nf1, #main")
+	if !sel.HasAllClasses("one", "even row") {
+		t.Error("Expected #nf1 to have classes one, even and row")
+	}
+	if sel.HasAllClasses("one", "zzz") {
+		t.Error("Expected no element to have classes one and zzz")
+	}
+	if sel.HasAllClasses() {
+		t.Error("Expected false when no class name is provided")
+	}
+}
+
+func TestHasAnyClass(t *testing.T) {
+	sel := Doc2().Find("#nf1, #main")
+	if !sel.HasAnyClass("zzz", "even") {
+		t.Error("Expected #nf1 to have class even")
+	}
+	if sel.HasAnyClass("zzz yyy") {
+		t.Error("Expected no element to have classes zzz or yyy")
+	}
+	if sel.HasAnyClass() {
+		t.Error("Expected false when no class name is provided")
+	}
+}
+
+func TestCss(t *testing.T) {
+	sel := Doc2Clone().Find("#main")
+	sel.SetAttr("style", "color: red; MARGIN-TOP:0 ; --Brand-Color: #fff; background: url('a;b.png') no-repeat")
+
+	cases := map[string]string{
+		"color":         "red",
+		"margin-top":    "0",
+		"Margin-Top":    "0",
+		"--Brand-Color": "#fff",
+		"--brand-color": "",
+		"background":    "url('a;b.png') no-repeat",
+		"padding":       "",
+	}
+	for prop, exp := range cases {
+		if got := sel.Css(prop); got != exp {
+			t.Errorf("Expected %s to be %q, got %q.", prop, exp, got)
+		}
+	}
+}
+
+func TestCssImportant(t *testing.T) {
+	sel := Doc2Clone().Find("#main")
+	sel.SetAttr("style", "color: red !important; color: blue; width: 1px ! IMPORTANT")
+
+	if got := sel.Css("color"); got != "red" {
+		t.Errorf("Expected the !important declaration to win, got %q.", got)
+	}
+	if got := sel.Css("width"); got != "1px" {
+		t.Errorf("Expected width without the !important flag, got %q.", got)
+	}
+}
+
+func TestCssInvalid(t *testing.T) {
+	sel := Doc2Clone().Find("#main")
+	sel.SetAttr("style", "color; :red; /* width: 2px; */ height: 1px; top:")
+
+	if got := sel.Css("height"); got != "1px" {
+		t.Errorf("Expected height to be 1px, got %q.", got)
+	}
+	sel.SetCss("top", "0")
+	if style, _ := sel.Attr("style"); style != "color; :red; /* width: 2px; */ height: 1px; top:; top: 0;" {
+		t.Errorf("Expected invalid declarations and comments to be preserved, got %q.", style)
+	}
+	sel.RemoveCss("height", "top")
+	if style, _ := sel.Attr("style"); style != "color; :red; /* width: 2px; */ top:;" {
+		t.Errorf("Expected invalid declarations and comments to be preserved, got %q.", style)
+	}
+}
+
+func TestSetCssInvalidValue(t *testing.T) {
+	sel := Doc2Clone().Find("#main")
+	sel.SetAttr("style", "color: blue; /* note */")
+
+	for _, val := range []string{
+		"red; x: y",
+		"red } p { color: green",
+		"url(a.png",
+		`"red`,
+		"red !important; top: 0 !important",
+		"red !x",
+		"red /* comment",
+	} {
+		sel.SetCss("color", val)
+		if style, _ := sel.Attr("style"); style != "color: blue; /* note */" {
+			t.Errorf("Expected %q to be ignored, got %q.", val, style)
+		}
+	}
+	sel.SetCss("x:y", "0")
+	if style, _ := sel.Attr("style"); style != "color: blue; /* note */" {
+		t.Errorf("Expected invalid name to be ignored, got %q.", style)
+	}
+
+	sel.SetCss("background", "url(a;b.png) !important")
+	if got := sel.Css("background"); got != "url(a;b.png)" {
+		t.Errorf("Expected url(a;b.png), got %q.", got)
+	}
+	if style, _ := sel.Attr("style"); style != "color: blue; /* note */ background: url(a;b.png) !important;" {
+		t.Errorf("Unexpected style %q.", style)
+	}
+}
+
+func TestCssEmpty(t *testing.T) {
+	if got := Doc().Find("zzzz").Css("color"); got != "" {
+		t.Errorf("Expected empty value, got %q.", got)
+	}
+	if got := Doc2().Find("#main").Css("color"); got != "" {
+		t.Errorf("Expected empty value, got %q.", got)
+	}
+}
+
+func TestSetCss(t *testing.T) {
+	sel := Doc2Clone().Find(".one")
+	sel.SetAttr("style", "color: red; foo-unknown: bar; margin: 0 !important")
+
+	sel.SetCss("color", "blue").SetCss("padding", "1px !important").SetCss("MARGIN", "2px")
+	sel.Each(func(i int, s *Selection) {
+		exp := "color: blue; foo-unknown: bar; margin: 2px; padding: 1px !important;"
+		if style, _ := s.Attr("style"); style != exp {
+			t.Errorf("Expected style %q, got %q.", exp, style)
+		}
+	})
+}
+
+func TestSetCssNew(t *testing.T) {
+	sel := Doc2Clone().Find("#main")
+	sel.SetCss("display", "none")
+
+	if style, _ := sel.Attr("style"); style != "display: none;" {
+		t.Errorf("Expected style %q, got %q.", "display: none;", style)
+	}
+}
+
+func TestSetCssDuplicates(t *testing.T) {
+	sel := Doc2Clone().Find("#main")
+	sel.SetAttr("style", "color: red; width: 1px; color: blue")
+	sel.SetCss("color", "green")
+
+	if style, _ := sel.Attr("style"); style != "color: green; width: 1px;" {
+		t.Errorf("Expected style %q, got %q.", "color: green; width: 1px;", style)
+	}
+}
+
+func TestSetCssEmptyValue(t *testing.T) {
+	sel := Doc2Clone().Find("#main")
+	sel.SetAttr("style", "color: red")
+	sel.SetCss("color", "")
+
+	if _, ok := sel.Attr("style"); ok {
+		t.Error("Expected style attribute to be removed.")
+	}
+}
+
+func TestSetCssMap(t *testing.T) {
+	sel := Doc2Clone().Find("#main")
+	sel.SetAttr("style", "width: 1px")
+	sel.SetCssMap(map[string]string{
+		"height": "2px",
+		"color":  "red",
+		"width":  "3px",
+	})
+
+	exp := "width: 3px; color: red; height: 2px;"
+	if style, _ := sel.Attr("style"); style != exp {
+		t.Errorf("Expected style %q, got %q.", exp, style)
+	}
+}
+
+func TestRemoveCss(t *testing.T) {
+	sel := Doc2Clone().Find("#main")
+	sel.SetAttr("style", "color: red; width: 1px; height: 2px")
+	sel.RemoveCss("color", "HEIGHT")
+
+	if style, _ := sel.Attr("style"); style != "width: 1px;" {
+		t.Errorf("Expected style %q, got %q.", "width: 1px;", style)
+	}
+
+	sel.RemoveCss("width")
+	if _, ok := sel.Attr("style"); ok {
+		t.Error("Expected style attribute to be removed.")
+	}
+}
+
+func TestRemoveCssNoStyle(t *testing.T) {
+	sel := Doc2Clone().Find("#main")
+	sel.RemoveCss("color")
+
+	if _, ok := sel.Attr("style"); ok {
+		t.Error("Expected no style attribute.")
+	}
+}
+
+func TestData(t *testing.T) {
+	sel := Doc2Clone().Find("#main")
+	sel.SetAttr("data-foo-bar", "1").SetAttr("data-id", "42")
+
+	cases := []struct {
+		key    string
+		val    string
+		exists bool
+	}{
+		{"fooBar", "1", true},
+		{"foo-bar", "1", true},
+		{"id", "42", true},
+		{"foobar", "", false},
+		{"missing", "", false},
+	}
+	for i, c := range cases {
+		val, ok := sel.Data(c.key)
+		if ok != c.exists || val != c.val {
+			t.Errorf("[%d] - expected %q, %v for key %s, got %q, %v", i, c.val, c.exists, c.key, val, ok)
+		}
+	}
+}
+
+func TestDataEmpty(t *testing.T) {
+	if _, ok := Doc().Find("zzzz").Data("foo"); ok {
+		t.Error("Expected no data on empty selection.")
+	}
+	if m := Doc().Find("zzzz").DataMap(); m != nil {
+		t.Errorf("Expected nil map on empty selection, got %v.", m)
+	}
+}
+
+func TestSetData(t *testing.T) {
+	sel := Doc2Clone().Find(".one")
+	sel.SetData("userId", "7").SetData("item-count", "3")
+
+	sel.Each(func(i int, s *Selection) {
+		if val, _ := s.Attr("data-user-id"); val != "7" {
+			t.Errorf("Expected data-user-id to be 7, got %q.", val)
+		}
+		if val, _ := s.Attr("data-item-count"); val != "3" {
+			t.Errorf("Expected data-item-count to be 3, got %q.", val)
+		}
+	})
+}
+
+func TestDataMap(t *testing.T) {
+	sel := Doc2Clone().Find("#main")
+	sel.SetAttr("data-foo-bar", "1").SetAttr("data-x-1", "2").SetAttr("data--lead", "3").SetAttr("datax", "4")
+
+	m := sel.DataMap()
+	exp := map[string]string{
+		"fooBar": "1",
+		"x-1":    "2",
+		"Lead":   "3",
+	}
+	if len(m) != len(exp) {
+		t.Errorf("Expected %d entries, got %v.", len(exp), m)
+	}
+	for k, v := range exp {
+		if m[k] != v {
+			t.Errorf("Expected %s to be %q, got %q.", k, v, m[k])
+		}
+	}
+}
+
+func TestDataJSON(t *testing.T) {
+	sel := Doc2Clone().Find("#main")
+	sel.SetData("config", `{"name":"x","ids":[1,2]}`).SetData("bad", "{")
+
+	var v struct {
+		Name string
+		Ids  []int
+	}
+	if e := sel.DataJSON("config", &v); e != nil {
+		t.Fatalf("Expected no error, got %s.", e)
+	}
+	if v.Name != "x" || len(v.Ids) != 2 {
+		t.Errorf("Expected decoded payload, got %+v.", v)
+	}
+
+	if e := sel.DataJSON("bad", &v); e == nil {
+		t.Error("Expected a decoding error.")
+	}
+	if e := sel.DataJSON("missing", &v); e != ErrDataNotFound {
+		t.Errorf("Expected error %v, got %v.", ErrDataNotFound, e)
+	}
+}
+
+func TestAttrOr(t *testing.T) {
+	sel := Doc2().Find("#main")
+	if val := sel.AttrOr("id", "def"); val != "main" {
+		t.Errorf("Expected id to be main, got %q.", val)
+	}
+	if val := sel.AttrOr("zzzz", "def"); val != "def" {
+		t.Errorf("Expected default value, got %q.", val)
+	}
+	if val := Doc().Find("zzzz").AttrOr("id", "def"); val != "def" {
+		t.Errorf("Expected default value on empty selection, got %q.", val)
+	}
+}
+
+func TestAttrs(t *testing.T) {
+	sel := Doc2Clone().Find("#main")
+	sel.SetAttr("title", "t").SetAttr("data-x", "1")
+
+	m := sel.Attrs()
+	exp := map[string]string{"id": "main", "title": "t", "data-x": "1"}
+	if len(m) != len(exp) {
+		t.Errorf("Expected %d attributes, got %v.", len(exp), m)
+	}
+	for k, v := range exp {
+		if m[k] != v {
+			t.Errorf("Expected %s to be %q, got %q.", k, v, m[k])
+		}
+	}
+	if m := Doc().Find("zzzz").Attrs(); m != nil {
+		t.Errorf("Expected nil map on empty selection, got %v.", m)
+	}
+}
+
+func TestHasAttr(t *testing.T) {
+	sel := Doc2().Find("div")
+	if !sel.HasAttr("id") {
+		t.Error("Expected a div to have an id attribute.")
+	}
+	if sel.HasAttr("zzzz") {
+		t.Error("Expected no div to have a zzzz attribute.")
+	}
+}
+
+func TestRemoveAttrs(t *testing.T) {
+	sel := Doc2Clone().Find("#main")
+	sel.SetAttr("a", "1").SetAttr("b", "2").SetAttr("c", "3")
+	sel.RemoveAttrs("a", "c", "zzzz")
+
+	assertAttrOrder(t, sel, "id", "b")
+}
+
+func TestRemoveAttrsMatching(t *testing.T) {
+	sel := Doc2Clone().Find("#main")
+	sel.SetAttr("onclick", "x()").SetAttr("title", "t").SetAttr("onload", "y()")
+	sel.RemoveAttrsMatching(func(name string) bool {
+		return strings.HasPrefix(name, "on")
+	})
+
+	assertAttrOrder(t, sel, "id", "title")
+}
+
+func TestRenameAttr(t *testing.T) {
+	sel := Doc2Clone().Find("#main")
+	sel.SetAttr("data-src", "a.png").SetAttr("title", "t").SetAttr("src", "old.png")
+	sel.RenameAttr("data-src", "src")
+
+	assertAttrOrder(t, sel, "id", "src", "title")
+	if val, _ := sel.Attr("src"); val != "a.png" {
+		t.Errorf("Expected src to be a.png, got %q.", val)
+	}
+
+	// No-op when the attribute does not exist
+	sel.RenameAttr("zzzz", "title")
+	assertAttrOrder(t, sel, "id", "src", "title")
+}
+
+func TestNamespacedAttr(t *testing.T) {
+	doc, e := NewDocumentFromReader(strings.NewReader(`<svg><a xlink:href="#x" href="#y"><use/></a></svg>`))
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	a := doc.Find("a")
+	if val, ok := a.Attr("xlink:href"); !ok || val != "#x" {
+		t.Errorf("Expected xlink:href to be #x, got %q.", val)
+	}
+	if val, _ := a.Attr("href"); val != "#y" {
+		t.Errorf("Expected href to be #y, got %q.", val)
+	}
+	if m := a.Attrs(); m["xlink:href"] != "#x" || m["href"] != "#y" {
+		t.Errorf("Expected qualified names in Attrs, got %v.", m)
+	}
+
+	use := doc.Find("use").SetAttr("xlink:href", "#z")
+	if ns := use.Get(0).Attr[0].Namespace; ns != "xlink" {
+		t.Errorf("Expected xlink namespace on the new attribute, got %q.", ns)
+	}
+	if h, _ := doc.Find("svg").Html(); !strings.Contains(h, `<use xlink:href="#z">`) {
+		t.Errorf("Expected the namespaced attribute to be rendered, got %s.", h)
+	}
+
+	a.RemoveAttr("xlink:href")
+	assertAttrOrder(t, a, "href")
+}
+
+func assertAttrOrder(t *testing.T, sel *Selection, names ...string) {
+	attrs := sel.Get(0).Attr
+	if len(attrs) != len(names) {
+		t.Errorf("Expected %d attributes, got %+v.", len(names), attrs)
+		return
+	}
+	for i, a := range attrs {
+		if qualifiedAttrName(a) != names[i] {
+			t.Errorf("Expected attribute %d to be %s, got %s.", i, names[i], qualifiedAttrName(a))
+		}
+	}
+}
+
+const formHtml = `<form id="f1">
+<input type="checkbox" id="c1" checked="false">
+<input type="checkbox" id="c2">
+<input type="radio" name="r" id="r1" checked>
+<input type="radio" name="r" id="r2">
+<input type="text" id="t1" disabled readonly>
+<textarea id="ta">some text</textarea>
+<select id="s1"><option value="a">A</option><optgroup><option id="o2">  B
+  b </option></optgroup><option selected>C</option></select>
+<select id="s2" multiple><option>A</option><option>B</option></select>
+<select id="s3"></select>
+</form>
+<form><input type="radio" name="r" id="r3" checked></form>`
+
+func TestProp(t *testing.T) {
+	doc := loadString(t, formHtml+`<a id="l1" href="/x">x</a>`)
+
+	cases := []struct {
+		sel  string
+		prop string
+		val  bool
+	}{
+		{"#c1", "checked", true},
+		{"#c2", "checked", false},
+		{"#r1", "CHECKED", true},
+		{"#t1", "disabled", true},
+		{"#t1", "readonly", true},
+		{"#t1", "required", false},
+		{"#s2", "multiple", true},
+		{"#l1", "href", false},
+		{"zzzz", "checked", false},
+	}
+	for i, c := range cases {
+		if got := doc.Find(c.sel).Prop(c.prop); got != c.val {
+			t.Errorf("[%d] - expected %s on %s to be %v, got %v", i, c.prop, c.sel, c.val, got)
+		}
+	}
+}
+
+func TestSetProp(t *testing.T) {
+	doc := loadString(t, formHtml)
+
+	doc.Find("#c2").SetProp("checked", true)
+	if val, ok := doc.Find("#c2").Attr("checked"); !ok || val != "" {
+		t.Errorf("Expected checked attribute with empty value, got %q, %v.", val, ok)
+	}
+	doc.Find("#c1").SetProp("checked", false)
+	if doc.Find("#c1").Prop("checked") {
+		t.Error("Expected #c1 to be unchecked.")
+	}
+
+	doc.Find("#t1").SetProp("value", true)
+	if doc.Find("#t1").HasAttr("value") {
+		t.Error("Expected non-boolean property to be ignored.")
+	}
+}
+
+func TestSetPropRadioGroup(t *testing.T) {
+	doc := loadString(t, formHtml)
+
+	doc.Find("#r2").SetProp("checked", true)
+	if doc.Find("#r1").Prop("checked") {
+		t.Error("Expected #r1 to be unchecked by checking #r2.")
+	}
+	if !doc.Find("#r2").Prop("checked") {
+		t.Error("Expected #r2 to be checked.")
+	}
+	if !doc.Find("#r3").Prop("checked") {
+		t.Error("Expected #r3 in another form to stay checked.")
+	}
+}
+
+func TestSetPropSelected(t *testing.T) {
+	doc := loadString(t, formHtml)
+
+	doc.Find("#o2").SetProp("selected", true)
+	if i := doc.Find("#s1").SelectedIndex(); i != 1 {
+		t.Errorf("Expected selected index 1, got %d.", i)
+	}
+	assertLength(t, doc.Find("#s1 option[selected]").Nodes, 1)
+
+	doc.Find("#s2 option").SetProp("selected", true)
+	assertLength(t, doc.Find("#s2 option[selected]").Nodes, 2)
+}
+
+func TestTagName(t *testing.T) {
+	doc := loadString(t, `<div></div><svg><foreignObject></foreignObject></svg>`)
+	if n := doc.Find("div").TagName(); n != "DIV" {
+		t.Errorf("Expected DIV, got %q.", n)
+	}
+	if n := doc.Find("svg").Children().TagName(); n != "foreignObject" {
+		t.Errorf("Expected foreignObject, got %q.", n)
+	}
+	if n := doc.Find("zzzz").TagName(); n != "" {
+		t.Errorf("Expected empty tag name, got %q.", n)
+	}
+}
+
+func TestId(t *testing.T) {
+	if id := Doc2().Find("#main").Id(); id != "main" {
+		t.Errorf("Expected main, got %q.", id)
+	}
+	if id := Doc2().Find("body").Id(); id != "" {
+		t.Errorf("Expected empty id, got %q.", id)
+	}
+}
+
+func TestHref(t *testing.T) {
+	doc := loadString(t, `<a id="a1" href="/x?y=1"></a><a id="a2" href="http://other.org/z"></a><a id="a3"></a><a id="a4" href=" rel "></a>`)
+
+	if h := doc.Find("#a1").Href(); h != "/x?y=1" {
+		t.Errorf("Expected unresolved href without base URL, got %q.", h)
+	}
+
+	doc.Url, _ = url.Parse("http://example.com/dir/page.html")
+	cases := map[string]string{
+		"#a1": "http://example.com/x?y=1",
+		"#a2": "http://other.org/z",
+		"#a3": "",
+		"#a4": "http://example.com/dir/rel",
+	}
+	for sel, exp := range cases {
+		if h := doc.Find(sel).Href(); h != exp {
+			t.Errorf("Expected href of %s to be %q, got %q.", sel, exp, h)
+		}
+	}
+}
+
+func TestHrefBase(t *testing.T) {
+	doc := loadString(t, `<head><base href="/base/"></head><body><a href="x"></a></body>`)
+	doc.Url, _ = url.Parse("http://example.com/dir/page.html")
+
+	if h := doc.Find("a").Href(); h != "http://example.com/base/x" {
+		t.Errorf("Expected href resolved against base, got %q.", h)
+	}
+}
+
+func TestVal(t *testing.T) {
+	doc := loadString(t, formHtml+`<input id="t2" value="v"><div id="d" value="dv"></div>`)
+
+	cases := map[string]string{
+		"#c1":  "on",
+		"#t1":  "",
+		"#t2":  "v",
+		"#ta":  "some text",
+		"#o2":  "B b",
+		"#s1":  "C",
+		"#s2":  "",
+		"#s3":  "",
+		"#d":   "dv",
+		"zzzz": "",
+	}
+	for sel, exp := range cases {
+		if v := doc.Find(sel).Val(); v != exp {
+			t.Errorf("Expected value of %s to be %q, got %q.", sel, exp, v)
+		}
+	}
+}
+
+func TestSelectedIndex(t *testing.T) {
+	doc := loadString(t, formHtml+`<select id="s4"><option>A</option><option>B</option></select>
+<select id="s5"><option>A</option><option selected>B</option><option selected>C</option></select>
+<select id="s6" multiple><option>A</option><option selected>B</option><option selected>C</option></select>`)
+
+	cases := map[string]int{
+		"#s1":  2,
+		"#s2":  -1,
+		"#s3":  -1,
+		"#s4":  0,
+		"#s5":  2,
+		"#s6":  1,
+		"#c1":  -1,
+		"zzzz": -1,
+	}
+	for sel, exp := range cases {
+		if i := doc.Find(sel).SelectedIndex(); i != exp {
+			t.Errorf("Expected selected index of %s to be %d, got %d.", sel, exp, i)
+		}
+	}
+}
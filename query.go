@@ -1,7 +1,6 @@
 package goquery
 
 import (
-	"code.google.com/p/cascadia"
 	"golang.org/x/net/html"
 )
 
@@ -9,7 +8,7 @@ import (
 // returns true if at least one of these elements matches.
 func (s *Selection) Is(selector string) bool {
 	if len(s.Nodes) > 0 {
-		return s.IsMatcher(cascadia.MustCompile(selector))
+		return s.IsMatcher(compileMatcher(selector, s))
 	}
 
 	return false
@@ -0,0 +1,324 @@
+package goquery
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Minimum number of records and of columns of a RecordTable.
+const (
+	minRecords      = 3
+	minRecordFields = 2
+)
+
+// RecordTable is a region of repeated records, as detected by DetectRecords.
+type RecordTable struct {
+	// Container is the parent element of the records.
+	Container *Selection
+	// Records are the elements of the records, in document order.
+	Records *Selection
+	// Selector is a CSS selector matching the records in the document.
+	Selector string
+	// Columns are the fields found in most of the records.
+	Columns []RecordColumn
+	// Rows are the values of the columns, one row per record. The value of a
+	// field missing from a record is empty.
+	Rows [][]string
+}
+
+// RecordColumn is a field of the records of a RecordTable.
+type RecordColumn struct {
+	// Selector is a CSS selector matching the field within a record, to be
+	// used with Find on the record.
+	Selector string
+	// Attr is the attribute holding the value of the field (href for links
+	// and src for images), or empty if the value is the text of the field.
+	Attr string
+}
+
+// DetectRecords finds the regions of repeated records within the set of
+// matched elements (including the elements themselves), such as search
+// results, product grids or comment threads, and returns them as tables.
+//
+// The records are sibling elements with the same structure: at least 3
+// elements with the same tag name and stable class names (see
+// UniqueSelectorOptions.IsStable), whose elements repeated among their
+// siblings are at the same positions, sharing at least 2 fields. The fields
+// of a record are its descendants with some text of their own, its links
+// and its images, identified by their path of tag and class names from the
+// record.
+// The fields found in at least half of the records are aligned as the
+// columns of the table, in the order they first appear, and the values are
+// the text of the fields, with whitespace collapsed, or the value of their
+// href or src attribute.
+//
+// The tables are returned in the document order of their containers.
+func (s *Selection) DetectRecords() []RecordTable {
+	var tables []RecordTable
+	seen := make(map[*html.Node]bool)
+
+	for _, root := range s.Nodes {
+		for n := root; n != nil; n = nextInTree(n, root) {
+			if n.Type != html.ElementNode || seen[n] {
+				continue
+			}
+			seen[n] = true
+			tables = append(tables, detectRecordTables(n, s.document)...)
+		}
+	}
+
+	return tables
+}
+
+// Returns the tables of records among the children of the container.
+func detectRecordTables(container *html.Node, doc *Document) []RecordTable {
+	// Group the children by tag name, signature and shape, in order of first
+	// appearance
+	var keys []string
+	groups := make(map[string][]*html.Node)
+	for c := container.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode {
+			continue
+		}
+		key := c.Data + " " + elementSignature(c) + " " + recordShape(c)
+		if groups[key] == nil {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], c)
+	}
+
+	var tables []RecordTable
+	for _, key := range keys {
+		records := groups[key]
+		if len(records) < minRecords {
+			continue
+		}
+
+		// The fields of each record, and the number of records having them
+		fields := make([]map[RecordColumn]*html.Node, len(records))
+		var cols []RecordColumn
+		counts := make(map[RecordColumn]int)
+		for i, r := range records {
+			fields[i] = make(map[RecordColumn]*html.Node)
+			for _, f := range recordFields(r) {
+				if _, ok := fields[i][f.col]; ok {
+					continue
+				}
+				fields[i][f.col] = f.n
+				if counts[f.col] == 0 {
+					cols = append(cols, f.col)
+				}
+				counts[f.col]++
+			}
+		}
+		kept := cols[:0]
+		for _, col := range cols {
+			if counts[col]*2 >= len(records) {
+				kept = append(kept, col)
+			}
+		}
+		if len(kept) < minRecordFields {
+			continue
+		}
+
+		rows := make([][]string, len(records))
+		for i := range records {
+			rows[i] = make([]string, len(kept))
+			for j, col := range kept {
+				if n := fields[i][col]; n != nil {
+					rows[i][j] = recordFieldValue(n, col.Attr)
+				}
+			}
+		}
+
+		sel := newSingleSelection(container, doc)
+		tables = append(tables, RecordTable{
+			Container: sel,
+			Records:   pushStack(sel, records),
+			Selector:  recordsSelector(sel.UniqueSelector(UniqueSelectorOptions{}), records),
+			Columns:   kept,
+			Rows:      rows,
+		})
+	}
+	return tables
+}
+
+// Returns a selector matching exactly the records among the children of the
+// container, whose selector is given. The signature of the records is used,
+// with :not() for the stable classes of the other children matching it, or
+// the positions of the records if the classes can't tell them apart.
+func recordsSelector(container string, records []*html.Node) string {
+	r := records[0]
+	sig := elementSignature(r)
+	var not []string
+	seen := make(map[string]bool)
+	for c := r.Parent.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || isInSlice(records, c) || !matchesSignature(c, r) {
+			continue
+		}
+		extra := false
+		classes, _ := getClassesAndAttr(c, false)
+		for _, cl := range classes {
+			if !isStableName(cl) || nodeHasClass(r, cl) {
+				continue
+			}
+			extra = true
+			if !seen[cl] {
+				seen[cl] = true
+				not = append(not, ":not(."+cssEscape(cl)+")")
+			}
+		}
+		if !extra {
+			// The child has the same signature, but another shape
+			sels := make([]string, len(records))
+			for i, r := range records {
+				sels[i] = container + " > " + sig + ":nth-child(" + strconv.Itoa(elementIndex(r)) + ")"
+			}
+			return strings.Join(sels, ", ")
+		}
+	}
+	return container + " > " + sig + strings.Join(not, "")
+}
+
+// A field of a record.
+type recordField struct {
+	col RecordColumn
+	n   *html.Node
+}
+
+// Returns the fields of the record, in document order.
+func recordFields(r *html.Node) []recordField {
+	var fields []recordField
+
+	var walk func(n *html.Node, path string)
+	walk = func(n *html.Node, path string) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			step := elementSignature(c)
+			if !isUniqueSignature(c) {
+				step += ":nth-of-type(" + strconv.Itoa(typeIndex(c)) + ")"
+			}
+			if path != "" {
+				step = path + " > " + step
+			}
+
+			if hasOwnText(c) {
+				fields = append(fields, recordField{RecordColumn{Selector: step}, c})
+			}
+			switch c.Data {
+			case "a":
+				if _, ok := getAttributeValue("href", c); ok {
+					fields = append(fields, recordField{RecordColumn{Selector: step, Attr: "href"}, c})
+				}
+			case "img":
+				if _, ok := getAttributeValue("src", c); ok {
+					fields = append(fields, recordField{RecordColumn{Selector: step, Attr: "src"}, c})
+				}
+			}
+			walk(c, step)
+		}
+	}
+	walk(r, "")
+
+	return fields
+}
+
+func recordFieldValue(n *html.Node, attr string) string {
+	if attr != "" {
+		val, _ := getAttributeValue(attr, n)
+		return val
+	}
+	return strings.Join(strings.Fields(getNodeText(n)), " ")
+}
+
+// Returns the tag name and stable class names of the element, as a CSS
+// selector.
+func elementSignature(n *html.Node) string {
+	sig := typeSelector(n)
+	classes, _ := getClassesAndAttr(n, false)
+	for _, cl := range classes {
+		if isStableName(cl) {
+			sig += "." + cssEscape(cl)
+		}
+	}
+	return sig
+}
+
+// Returns the shape of the subtree of the record that its fields depend on:
+// the paths of the elements whose signature is not unique among their
+// siblings, with their position. The records with the same shape have their
+// repeated fields at the same positions, so that their rows are aligned.
+func recordShape(r *html.Node) string {
+	var shape []string
+	var walk func(n *html.Node, path string)
+	walk = func(n *html.Node, path string) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			step := path + " > " + c.Data + " " + elementSignature(c)
+			if !isUniqueSignature(c) {
+				step += ":" + strconv.Itoa(typeIndex(c))
+				shape = append(shape, step)
+			}
+			walk(c, step)
+		}
+	}
+	walk(r, "")
+	return strings.Join(shape, ",")
+}
+
+// Checks if no sibling of the element matches its signature.
+func isUniqueSignature(n *html.Node) bool {
+	if n.Parent == nil {
+		return true
+	}
+	for c := n.Parent.FirstChild; c != nil; c = c.NextSibling {
+		if c != n && c.Type == html.ElementNode && matchesSignature(c, n) {
+			return false
+		}
+	}
+	return true
+}
+
+// Checks if the element matches the signature of the element sig, that is
+// if it has its tag name, unless the signature has no tag name, and its
+// stable classes.
+func matchesSignature(n, sig *html.Node) bool {
+	if typeSelector(sig) != "*" && n.Data != sig.Data {
+		return false
+	}
+	classes, _ := getClassesAndAttr(sig, false)
+	for _, cl := range classes {
+		if isStableName(cl) && !nodeHasClass(n, cl) {
+			return false
+		}
+	}
+	return true
+}
+
+// Checks if the node has a child text node that is not only whitespace.
+func hasOwnText(n *html.Node) bool {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.TextNode && !isWhitespaceText(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the position of the element among its sibling elements of the
+// same tag name, starting at 1, as used by :nth-of-type.
+func typeIndex(n *html.Node) int {
+	i := 1
+	for c := n.PrevSibling; c != nil; c = c.PrevSibling {
+		if c.Type == html.ElementNode && c.Data == n.Data {
+			i++
+		}
+	}
+	return i
+}
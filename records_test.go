@@ -0,0 +1,107 @@
+package goquery
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDetectRecords(t *testing.T) {
+	doc := loadString(t, `<div id="results">
+<div class="product css-9f8e7d"><h2 class="name">A</h2><span class="price">1</span><a href="/a">more</a></div>
+<div class="product css-1a2b3c"><h2 class="name">B</h2><span class="price">2</span><a href="/b">more</a></div>
+<div class="ad">Buy</div>
+<div class="product"><h2 class="name"> C
+  c </h2><a href="/c">more</a></div>
+</div>
+<ul><li>x</li><li>y</li><li>z</li></ul>`)
+
+	tables := doc.DetectRecords()
+	if len(tables) != 1 {
+		t.Fatalf("expected 1 table, got %d", len(tables))
+	}
+	tbl := tables[0]
+	if !tbl.Container.IsSelection(doc.Find("#results")) {
+		t.Error("expected #results to be the container")
+	}
+	if tbl.Selector != "#results > div.product" {
+		t.Errorf("unexpected selector %q", tbl.Selector)
+	}
+	if !doc.Find(tbl.Selector).IsSelection(tbl.Records) {
+		t.Error("expected the selector to match the records")
+	}
+
+	expectedCols := []RecordColumn{{"h2.name", ""}, {"span.price", ""}, {"a", ""}, {"a", "href"}}
+	if !reflect.DeepEqual(tbl.Columns, expectedCols) {
+		t.Errorf("expected columns %v, got %v", expectedCols, tbl.Columns)
+	}
+	expectedRows := [][]string{
+		{"A", "1", "more", "/a"},
+		{"B", "2", "more", "/b"},
+		{"C c", "", "more", "/c"},
+	}
+	if !reflect.DeepEqual(tbl.Rows, expectedRows) {
+		t.Errorf("expected rows %v, got %v", expectedRows, tbl.Rows)
+	}
+
+	// The column selectors find the fields from the records
+	tbl.Records.Each(func(i int, rec *Selection) {
+		if txt := rec.Find(tbl.Columns[0].Selector).Text(); txt != tbl.Records.Eq(i).Find("h2").Text() {
+			t.Errorf("%d: unexpected field %q", i, txt)
+		}
+	})
+}
+
+func TestDetectRecordsNone(t *testing.T) {
+	if tables := Doc2().DetectRecords(); len(tables) != 0 {
+		t.Errorf("expected no table, got %d", len(tables))
+	}
+	if tables := Doc2().Find("none").DetectRecords(); len(tables) != 0 {
+		t.Errorf("expected no table, got %d", len(tables))
+	}
+}
+
+func TestDetectRecordsSelector(t *testing.T) {
+	doc := loadString(t, `<ul id="list">
+<li class="item"><b>A</b><i>1</i></li>
+<li class="item featured"><b>F</b><i>0</i></li>
+<li class="item"><b>B</b><i>2</i></li>
+<li class="item"><b>C</b><i>3</i></li>
+</ul>`)
+
+	tables := doc.DetectRecords()
+	if len(tables) != 1 {
+		t.Fatalf("expected 1 table, got %d", len(tables))
+	}
+	tbl := tables[0]
+	if tbl.Selector != "#list > li.item:not(.featured)" {
+		t.Errorf("unexpected selector %q", tbl.Selector)
+	}
+	assertLength(t, doc.Find(tbl.Selector).Nodes, 3)
+	if !doc.Find(tbl.Selector).IsSelection(tbl.Records) {
+		t.Error("expected the selector to match the records")
+	}
+}
+
+func TestDetectRecordsShape(t *testing.T) {
+	doc := loadString(t, `<div id="list">
+<p><span>A</span><span>1</span></p>
+<p><span>B</span><span>2</span></p>
+<p><span>C</span><span>3</span></p>
+<p><span>x</span></p>
+<p><span>y</span><span>z</span><span>w</span></p>
+</div>`)
+
+	tables := doc.DetectRecords()
+	if len(tables) != 1 {
+		t.Fatalf("expected 1 table, got %d", len(tables))
+	}
+	tbl := tables[0]
+	expectedRows := [][]string{{"A", "1"}, {"B", "2"}, {"C", "3"}}
+	if !reflect.DeepEqual(tbl.Rows, expectedRows) {
+		t.Errorf("expected rows %v, got %v", expectedRows, tbl.Rows)
+	}
+	assertLength(t, doc.Find(tbl.Selector).Nodes, 3)
+	if !doc.Find(tbl.Selector).IsSelection(tbl.Records) {
+		t.Errorf("expected the selector %q to match the records", tbl.Selector)
+	}
+}
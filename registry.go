@@ -0,0 +1,347 @@
+package goquery
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync"
+
+	"code.google.com/p/cascadia"
+	"golang.org/x/net/html"
+)
+
+// PseudoClassFunc implements a custom pseudo-class, checking if the node
+// matches it. The arg is the argument of the pseudo-class, such as "de" for
+// :lang-is(de), with the surrounding whitespace and quotes removed. It is
+// empty if the pseudo-class has no argument.
+type PseudoClassFunc func(n *html.Node, arg string) bool
+
+// SelectorRegistry holds custom pseudo-classes that can be used in selector
+// strings, along with the ones supported by cascadia. It is safe for
+// concurrent use.
+type SelectorRegistry struct {
+	mu      sync.RWMutex
+	pseudos map[string]PseudoClassFunc
+	// The pseudo-classes depending on the document of the nodes, such as
+	// :visible, which are bound to doc when a selector is compiled
+	docPseudos map[string]docPseudoFunc
+	doc        *Document
+}
+
+// Returns the function of a pseudo-class for the nodes of the document,
+// which may be nil.
+type docPseudoFunc func(doc *Document) PseudoClassFunc
+
+// DefaultSelectorRegistry holds the custom pseudo-classes available to the
+// string-selector methods of Selection and Streamer, such as Find or Filter.
+// The :visible pseudo-class is registered by default (see RegisterVisible),
+// it can be replaced by registering another one with the same name.
+var DefaultSelectorRegistry = NewSelectorRegistry()
+
+// NewSelectorRegistry returns a SelectorRegistry with no pseudo-classes
+// registered.
+func NewSelectorRegistry() *SelectorRegistry {
+	return &SelectorRegistry{
+		pseudos:    make(map[string]PseudoClassFunc),
+		docPseudos: make(map[string]docPseudoFunc),
+	}
+}
+
+// Register registers the pseudo-class with the name, without the leading
+// colon, such as "price" for :price. The names are case-insensitive, and a
+// registered pseudo-class takes precedence over the cascadia one with the
+// same name. It panics if the name is not a valid identifier or is "scope".
+// It returns the registry so that calls can be chained.
+func (reg *SelectorRegistry) Register(name string, f PseudoClassFunc) *SelectorRegistry {
+	name = checkPseudoName(name)
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	delete(reg.docPseudos, name)
+	reg.pseudos[name] = f
+	return reg
+}
+
+// Registers the pseudo-class depending on the document of the nodes, as
+// Register.
+func (reg *SelectorRegistry) registerDoc(name string, f docPseudoFunc) *SelectorRegistry {
+	name = checkPseudoName(name)
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	delete(reg.pseudos, name)
+	reg.docPseudos[name] = f
+	return reg
+}
+
+// Returns the pseudo-class name in lowercase, and panics if it is invalid.
+func checkPseudoName(name string) string {
+	name = strings.ToLower(name)
+	if name == "" || name == "scope" || (name[0] >= '0' && name[0] <= '9') {
+		panic("goquery: invalid pseudo-class name " + name)
+	}
+	for i := 0; i < len(name); i++ {
+		if !isSelectorNameChar(name[i]) {
+			panic("goquery: invalid pseudo-class name " + name)
+		}
+	}
+	return name
+}
+
+// Unregister removes the pseudo-class with the name, if it is registered.
+func (reg *SelectorRegistry) Unregister(name string) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	delete(reg.pseudos, strings.ToLower(name))
+	delete(reg.docPseudos, strings.ToLower(name))
+}
+
+// Returns a copy of the registry, bound to the document, to compile and
+// match selectors without holding the lock of the registry while the
+// pseudo-class functions, which may use the registry themselves, are called.
+func (reg *SelectorRegistry) snapshot(doc *Document) *SelectorRegistry {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	snap := &SelectorRegistry{
+		pseudos:    make(map[string]PseudoClassFunc, len(reg.pseudos)),
+		docPseudos: make(map[string]docPseudoFunc, len(reg.docPseudos)),
+		doc:        doc,
+	}
+	for name, f := range reg.pseudos {
+		snap.pseudos[name] = f
+	}
+	for name, f := range reg.docPseudos {
+		snap.docPseudos[name] = f
+	}
+	return snap
+}
+
+// Compile compiles the selector, with the pseudo-classes of the registry, in
+// a Matcher to be used with FindMatcher and the other ...Matcher methods.
+// The pseudo-classes of the registry can be used in the compound selectors
+// of the selector, but not within the arguments of other pseudo-classes such
+// as :not. As a Matcher has no scope, the selector can't be relative.
+func (reg *SelectorRegistry) Compile(selector string) (Matcher, error) {
+	return reg.compile(selector, nil, nil, false)
+}
+
+// MustCompile is like Compile, but panics if the selector is invalid.
+func (reg *SelectorRegistry) MustCompile(selector string) Matcher {
+	m, e := reg.Compile(selector)
+	if e != nil {
+		panic(e)
+	}
+	return m
+}
+
+// Compiles the selector for the nodes of the document, which may be nil,
+// using the nodes of the scope for its relative selector groups if relative
+// is true. The selector is compiled by cascadia if no group is relative or
+// uses a pseudo-class of the registry.
+func (reg *SelectorRegistry) compile(selector string, doc *Document, scope []*html.Node, relative bool) (Matcher, error) {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	if doc != reg.doc && len(reg.docPseudos) > 0 {
+		// The maps are shared, as they are read under the lock of reg
+		reg = &SelectorRegistry{pseudos: reg.pseudos, docPseudos: reg.docPseudos, doc: doc}
+	}
+
+	var abs []string
+	var sels []*complexSelector
+	for _, g := range splitSelectorGroups(selector) {
+		rel := isRelativeSelector(g)
+		if !rel && !reg.usesPseudos(g) {
+			abs = append(abs, g)
+			continue
+		}
+		if rel && !relative {
+			return nil, errors.New("goquery: relative selector without a scope: " + strings.TrimSpace(g))
+		}
+		cs, e := reg.parseComplexSelector(g)
+		if e != nil {
+			return nil, e
+		}
+		sels = append(sels, cs)
+	}
+	if len(sels) == 0 {
+		return compileCascadia(selector)
+	}
+
+	m := &complexMatcher{sels: sels, scope: make(map[*html.Node]bool, len(scope))}
+	if len(abs) > 0 {
+		var e error
+		if m.abs, e = compileCascadia(strings.Join(abs, ",")); e != nil {
+			return nil, e
+		}
+	}
+	for _, n := range scope {
+		m.scope[n] = true
+	}
+	return m, nil
+}
+
+func compileCascadia(selector string) (Matcher, error) {
+	sel, e := cascadia.Compile(selector)
+	if e != nil {
+		return nil, e
+	}
+	return sel, nil
+}
+
+// A registered pseudo-class used in a compound selector, and its argument.
+type pseudoCall struct {
+	f   PseudoClassFunc
+	arg string
+}
+
+// A Matcher for a compound selector using pseudo-classes of a registry. The
+// rest of the compound selector is compiled by cascadia, in base.
+type pseudoMatcher struct {
+	base  Matcher
+	calls []pseudoCall
+}
+
+// Match returns true if the node matches the compound selector.
+func (m *pseudoMatcher) Match(n *html.Node) bool {
+	if n.Type != html.ElementNode || !m.base.Match(n) {
+		return false
+	}
+	for _, c := range m.calls {
+		if !c.f(n, c.arg) {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchAll returns the node and its descendants that match the selector.
+func (m *pseudoMatcher) MatchAll(root *html.Node) []*html.Node {
+	return matchAllNodes(m, root)
+}
+
+// Filter returns the nodes that match the selector.
+func (m *pseudoMatcher) Filter(nodes []*html.Node) []*html.Node {
+	return filterMatching(m, nodes)
+}
+
+// Checks if the selector group uses a pseudo-class of the registry.
+func (reg *SelectorRegistry) usesPseudos(g string) bool {
+	if len(reg.pseudos) == 0 && len(reg.docPseudos) == 0 {
+		return false
+	}
+	found := false
+	scanSelector(g, func(i int, c byte, top bool) {
+		if top && c == ':' && reg.pseudoAt(g, i) != nil {
+			found = true
+		}
+	})
+	return found
+}
+
+// Returns the registered pseudo-class whose name follows the colon at index
+// i of the selector, or nil.
+func (reg *SelectorRegistry) pseudoAt(sel string, i int) PseudoClassFunc {
+	if i > 0 && sel[i-1] == ':' {
+		// A pseudo-element
+		return nil
+	}
+	j := i + 1
+	for j < len(sel) && isSelectorNameChar(sel[j]) {
+		j++
+	}
+	name := strings.ToLower(sel[i+1 : j])
+	if f := reg.pseudos[name]; f != nil {
+		return f
+	}
+	if f := reg.docPseudos[name]; f != nil {
+		return f(reg.doc)
+	}
+	return nil
+}
+
+// Compiles the compound selector, with the pseudo-classes of the registry.
+func (reg *SelectorRegistry) compileCompound(p string) (Matcher, error) {
+	var calls []pseudoCall
+	var rest bytes.Buffer
+	var err error
+	last := 0
+	scanSelector(p, func(i int, c byte, top bool) {
+		if err != nil || i < last || !top || c != ':' {
+			return
+		}
+		f := reg.pseudoAt(p, i)
+		if f == nil {
+			return
+		}
+		j := i + 1
+		for j < len(p) && isSelectorNameChar(p[j]) {
+			j++
+		}
+		call := pseudoCall{f: f}
+		if j < len(p) && p[j] == '(' {
+			end := closingParen(p, j)
+			if end < 0 {
+				err = errors.New("goquery: unclosed parenthesis in " + p)
+				return
+			}
+			call.arg = unquoteArg(p[j+1 : end])
+			j = end + 1
+		}
+		rest.WriteString(p[last:i])
+		last = j
+		calls = append(calls, call)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(calls) == 0 {
+		return compileCascadia(p)
+	}
+
+	rest.WriteString(p[last:])
+	base := rest.String()
+	if base == "" {
+		base = "*"
+	}
+	m, e := compileCascadia(base)
+	if e != nil {
+		return nil, e
+	}
+	return &pseudoMatcher{m, calls}, nil
+}
+
+// Returns the index of the parenthesis closing the one at index i of the
+// selector, or -1.
+func closingParen(sel string, i int) int {
+	depth := 0
+	var quote byte
+	for ; i < len(sel); i++ {
+		c := sel[i]
+		switch {
+		case c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// Returns the argument of a pseudo-class without the surrounding whitespace
+// and quotes.
+func unquoteArg(arg string) string {
+	arg = strings.TrimSpace(arg)
+	if len(arg) >= 2 && (arg[0] == '"' || arg[0] == '\'') && arg[len(arg)-1] == arg[0] {
+		return arg[1 : len(arg)-1]
+	}
+	return arg
+}
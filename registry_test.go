@@ -0,0 +1,77 @@
+package goquery
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+const registryHtml = `<div id="d1" lang="de"><p id="p1" data-price="12">a</p><p id="p2" data-price="30" class="x">b</p></div><div id="d2"><p id="p3" data-price="5" class="x">c</p><p id="p4">d</p></div>`
+
+func priceAbove(n *html.Node, arg string) bool {
+	val, ok := getAttributeValue("data-price", n)
+	if !ok {
+		return false
+	}
+	if arg == "" {
+		return true
+	}
+	price, _ := strconv.Atoi(val)
+	min, _ := strconv.Atoi(arg)
+	return price > min
+}
+
+func langIs(n *html.Node, arg string) bool {
+	for ; n != nil; n = n.Parent {
+		if lang, ok := getAttributeValue("lang", n); ok {
+			return strings.EqualFold(lang, arg)
+		}
+	}
+	return false
+}
+
+func TestSelectorRegistryCompile(t *testing.T) {
+	doc := loadString(t, registryHtml)
+	reg := NewSelectorRegistry().Register("price", priceAbove).Register("Lang-Is", langIs)
+
+	assertIds(t, doc.FindMatcher(reg.MustCompile(":price")), "#p1", "#p2", "#p3")
+	assertIds(t, doc.FindMatcher(reg.MustCompile("p:price(10)")), "#p1", "#p2")
+	assertIds(t, doc.FindMatcher(reg.MustCompile(`p.x:PRICE( "10" )`)), "#p2")
+	assertIds(t, doc.FindMatcher(reg.MustCompile(":lang-is(de) > :price(10):not(.x)")), "#p1")
+	assertIds(t, doc.FindMatcher(reg.MustCompile("div:lang-is(de) ~ div :price, #p4")), "#p3", "#p4")
+	assertIds(t, doc.FindMatcher(reg.MustCompile("p:first-child")), "#p1", "#p3")
+
+	for _, sel := range []string{":price(", "> p", "p:unknown", "p:price >"} {
+		if _, e := reg.Compile(sel); e == nil {
+			t.Errorf("%s: expected an error", sel)
+		}
+	}
+	for _, name := range []string{"scope", "a b", ""} {
+		func() {
+			defer assertPanic(t)
+			reg.Register(name, priceAbove)
+		}()
+	}
+
+	reg.Unregister("price")
+	if _, e := reg.Compile(":price"); e == nil {
+		t.Error("expected an error for an unregistered pseudo-class")
+	}
+}
+
+func TestSelectorRegistryDefault(t *testing.T) {
+	DefaultSelectorRegistry.Register("price", priceAbove)
+	defer DefaultSelectorRegistry.Unregister("price")
+	doc := loadString(t, registryHtml)
+
+	assertIds(t, doc.Find("p:price(10)"), "#p1", "#p2")
+	assertIds(t, doc.Find("div").Find("> :price"), "#p1", "#p2", "#p3")
+	assertIds(t, doc.Find("p").Filter(":price(20), #p4"), "#p2", "#p4")
+	assertIds(t, doc.Find("p").Not(":price"), "#p4")
+	assertIds(t, doc.Find("div").Has(".x:price(20)"), "#d1")
+	if !doc.Find("#p3").Is(":price") || doc.Find("#p4").Is(":price") {
+		t.Error("unexpected Is result")
+	}
+}
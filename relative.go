@@ -0,0 +1,329 @@
+package goquery
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Compiles the selector of a string-selector method called on the Selection,
+// whose nodes are the scope, with the pseudo-classes of the default registry.
+// The Selection may be nil. It panics if the selector is invalid, as
+// cascadia.MustCompile.
+func compileMatcher(selector string, s *Selection) Matcher {
+	var doc *Document
+	var scope []*html.Node
+	if s != nil {
+		doc, scope = s.document, s.Nodes
+	}
+	m, e := DefaultSelectorRegistry.compile(selector, doc, scope, true)
+	if e != nil {
+		panic(e)
+	}
+	return m
+}
+
+// A Matcher for a selector with groups that cascadia can't compile: the
+// relative selectors and the selectors using custom pseudo-classes. The
+// other groups are compiled by cascadia, in abs.
+type complexMatcher struct {
+	abs   Matcher
+	sels  []*complexSelector
+	scope map[*html.Node]bool
+}
+
+// Match returns true if the node matches one of the selector groups.
+func (m *complexMatcher) Match(n *html.Node) bool {
+	if m.abs != nil && m.abs.Match(n) {
+		return true
+	}
+	for _, cs := range m.sels {
+		if cs.match(n, m.scope) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchAll returns the node and its descendants that match the selector.
+func (m *complexMatcher) MatchAll(root *html.Node) []*html.Node {
+	return matchAllNodes(m, root)
+}
+
+// Filter returns the nodes that match the selector.
+func (m *complexMatcher) Filter(nodes []*html.Node) []*html.Node {
+	return filterMatching(m, nodes)
+}
+
+// Returns the node and its descendants that m matches, for the Matchers
+// implemented by their Match method.
+func matchAllNodes(m Matcher, root *html.Node) (result []*html.Node) {
+	for n := root; n != nil; n = nextInTree(n, root) {
+		if m.Match(n) {
+			result = append(result, n)
+		}
+	}
+	return
+}
+
+// Returns the nodes that m matches, for the Matchers implemented by their
+// Match method.
+func filterMatching(m Matcher, nodes []*html.Node) (result []*html.Node) {
+	for _, n := range nodes {
+		if m.Match(n) {
+			result = append(result, n)
+		}
+	}
+	return
+}
+
+// Returns the nodes that Find must search, in addition to the descendants of
+// the node: the following siblings of the node, and their descendants, if the
+// matcher has a selector group starting with a sibling combinator.
+func followingSearchRoots(n *html.Node, m Matcher) (roots []*html.Node) {
+	cm, ok := m.(*complexMatcher)
+	if !ok {
+		return nil
+	}
+	for _, cs := range cm.sels {
+		if cs.relative && (cs.lead == '+' || cs.lead == '~') {
+			for c := n.NextSibling; c != nil; c = c.NextSibling {
+				if c.Type == html.ElementNode {
+					roots = append(roots, c)
+				}
+			}
+			return
+		}
+	}
+	return nil
+}
+
+// A complex selector, parsed in its compound selectors and the combinators
+// between them. A relative selector starts at the scope, optionally filtered
+// by the compound selector following :scope, then has the combinator
+// leading to the first compound selector. If it has no compound selector, it
+// matches the scope itself.
+type complexSelector struct {
+	relative    bool
+	scopeFilter Matcher
+	lead        byte
+	compounds   []Matcher
+	combs       []byte
+}
+
+func (cs *complexSelector) match(n *html.Node, scope map[*html.Node]bool) bool {
+	if len(cs.compounds) == 0 {
+		return cs.matchScope(n, scope)
+	}
+	return cs.matchAt(n, len(cs.compounds)-1, scope)
+}
+
+func (cs *complexSelector) matchScope(n *html.Node, scope map[*html.Node]bool) bool {
+	return scope[n] && (cs.scopeFilter == nil || cs.scopeFilter.Match(n))
+}
+
+// Checks if the node matches the compound selector at index i, and the
+// preceding ones through their combinators, up to the scope for a relative
+// selector.
+func (cs *complexSelector) matchAt(n *html.Node, i int, scope map[*html.Node]bool) bool {
+	if n.Type != html.ElementNode || !cs.compounds[i].Match(n) {
+		return false
+	}
+	if i == 0 {
+		if !cs.relative {
+			return true
+		}
+		return matchCombinator(n, cs.lead, func(p *html.Node) bool {
+			return cs.matchScope(p, scope)
+		})
+	}
+	return matchCombinator(n, cs.combs[i-1], func(p *html.Node) bool {
+		return cs.matchAt(p, i-1, scope)
+	})
+}
+
+// Checks if a node related to n through the combinator satisfies f.
+func matchCombinator(n *html.Node, comb byte, f func(*html.Node) bool) bool {
+	switch comb {
+	case ' ':
+		for p := n.Parent; p != nil; p = p.Parent {
+			if f(p) {
+				return true
+			}
+		}
+	case '>':
+		return n.Parent != nil && f(n.Parent)
+	case '+':
+		p := prevElementSibling(n)
+		return p != nil && f(p)
+	case '~':
+		for p := prevElementSibling(n); p != nil; p = prevElementSibling(p) {
+			if f(p) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func prevElementSibling(n *html.Node) *html.Node {
+	for p := n.PrevSibling; p != nil; p = p.PrevSibling {
+		if p.Type == html.ElementNode {
+			return p
+		}
+	}
+	return nil
+}
+
+// Checks if the selector group starts with a combinator or :scope.
+func isRelativeSelector(g string) bool {
+	g = strings.TrimSpace(g)
+	if g == "" {
+		return false
+	}
+	switch g[0] {
+	case '>', '+', '~':
+		return true
+	}
+	return hasScopePrefix(g)
+}
+
+func hasScopePrefix(g string) bool {
+	if len(g) < len(":scope") || !strings.EqualFold(g[:len(":scope")], ":scope") {
+		return false
+	}
+	// Not the start of a longer name
+	rest := g[len(":scope"):]
+	return rest == "" || !isSelectorNameChar(rest[0])
+}
+
+func isSelectorNameChar(c byte) bool {
+	return c == '-' || c == '_' || c >= 0x80 || (c >= '0' && c <= '9') ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// Parses the selector group, compiling its compound selectors with the
+// pseudo-classes of the registry.
+func (reg *SelectorRegistry) parseComplexSelector(g string) (*complexSelector, error) {
+	g = strings.TrimSpace(g)
+	cs := &complexSelector{relative: isRelativeSelector(g)}
+
+	scoped := hasScopePrefix(g)
+	if scoped {
+		g = g[len(":scope"):]
+	}
+	parts, combs, e := splitCompounds(g)
+	if e != nil {
+		return nil, e
+	}
+
+	// The rest of the compound selector starting with :scope filters the scope
+	if scoped && g != "" && !strings.ContainsAny(g[:1], " \t\n\r\f>+~") {
+		if cs.scopeFilter, e = reg.compileCompound(parts[0]); e != nil {
+			return nil, e
+		}
+		parts, combs = parts[1:], combs[1:]
+	}
+	if len(parts) == 0 {
+		if !scoped {
+			return nil, errors.New("goquery: expected a selector after the combinator")
+		}
+		return cs, nil
+	}
+
+	cs.lead = combs[0]
+	for _, p := range parts {
+		if strings.Contains(strings.ToLower(p), ":scope") {
+			return nil, errors.New("goquery: :scope is only supported at the start of a selector")
+		}
+		m, e := reg.compileCompound(p)
+		if e != nil {
+			return nil, e
+		}
+		cs.compounds = append(cs.compounds, m)
+	}
+	cs.combs = combs[1:]
+	return cs, nil
+}
+
+// Splits the selector group in its compound selectors and the combinators
+// preceding each of them (a space for the descendant combinator, including
+// for a compound selector at the start of the group).
+func splitCompounds(g string) (parts []string, combs []byte, err error) {
+	comb := byte(' ')
+	pending := false
+	start := -1
+	flush := func(end int) {
+		if start >= 0 {
+			parts = append(parts, g[start:end])
+			combs = append(combs, comb)
+			comb, pending, start = ' ', false, -1
+		}
+	}
+
+	scanSelector(g, func(i int, c byte, top bool) {
+		switch {
+		case top && (c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'):
+			flush(i)
+		case top && (c == '>' || c == '+' || c == '~'):
+			flush(i)
+			if pending {
+				err = errors.New("goquery: unexpected combinator " + string(c))
+			}
+			comb, pending = c, true
+		case start < 0:
+			start = i
+		}
+	})
+	flush(len(g))
+	if pending && err == nil {
+		err = errors.New("goquery: expected a selector after the combinator")
+	}
+	return
+}
+
+// Splits the selector on the commas that separate its groups.
+func splitSelectorGroups(selector string) (groups []string) {
+	start := 0
+	scanSelector(selector, func(i int, c byte, top bool) {
+		if top && c == ',' {
+			groups = append(groups, selector[start:i])
+			start = i + 1
+		}
+	})
+	return append(groups, selector[start:])
+}
+
+// Calls f for each byte of the selector, with top set to true if the byte is
+// not within a string, brackets, parentheses or an escape sequence.
+func scanSelector(selector string, f func(i int, c byte, top bool)) {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(selector); i++ {
+		c := selector[i]
+		top := false
+		switch {
+		case c == '\\':
+			f(i, c, false)
+			if i+1 < len(selector) {
+				i++
+				f(i, selector[i], false)
+			}
+			continue
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '(':
+			depth++
+		case c == ']' || c == ')':
+			depth--
+		default:
+			top = depth == 0
+		}
+		f(i, c, top)
+	}
+}
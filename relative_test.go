@@ -0,0 +1,76 @@
+package goquery
+
+import (
+	"testing"
+)
+
+const relativeHtml = `<ul id="l1"><li id="a" class="x">a<ul id="l2"><li id="b">b</li></ul></li><li id="c">c</li><li id="d" class="x">d</li></ul><p id="p1">p</p><p id="p2" class="x">p</p>`
+
+func TestFindRelative(t *testing.T) {
+	doc := loadString(t, relativeHtml)
+	l1 := doc.Find("#l1")
+
+	assertIds(t, l1.Find("> li"), "#a", "#c", "#d")
+	assertIds(t, l1.Find("> li > ul > li"), "#b")
+	assertIds(t, l1.Find("> li.x, #b"), "#a", "#b", "#d")
+	assertIds(t, l1.Find("+ p"), "#p1")
+	assertIds(t, l1.Find("~ .x"), "#p2")
+	assertIds(t, doc.Find("#a").Find("~ li"), "#c", "#d")
+	assertIds(t, l1.Find(":scope > li:nth-child(2)"), "#c")
+	assertIds(t, doc.Find("ul").Find(":scope#l2 > li"), "#b")
+	assertIds(t, l1.FindFirst("~ p"), "#p1")
+	if !l1.Exists("> li#d") || l1.Exists("> li#b") {
+		t.Error("unexpected Exists result")
+	}
+	// Relative to each node of the selection
+	assertIds(t, doc.Find("ul").Find("> li"), "#a", "#b", "#c", "#d")
+}
+
+func TestFilterRelative(t *testing.T) {
+	doc := loadString(t, relativeHtml)
+	l1 := doc.Find("#l1")
+
+	assertIds(t, doc.Find("li").Filter(":scope.x"), "#a", "#d")
+	assertIds(t, doc.Find("ul").Not(":scope#l1"), "#l2")
+	assertIds(t, l1.ChildrenFiltered("> .x"), "#a", "#d")
+	assertIds(t, doc.Find("#a").SiblingsFiltered("+ li"), "#c")
+	assertIds(t, doc.Find("#a").NextAllFiltered("~ .x"), "#d")
+	assertIds(t, doc.Find("li").Has("> ul"), "#a")
+	assertIds(t, l1.Add("+ p"), "#l1", "#p1")
+	assertIds(t, doc.Find("#b").Closest(":scope"), "#b")
+	if !doc.Find("#a, #c").Is("+ li") || doc.Find("#a, #d").Is("+ li") {
+		t.Error("unexpected Is result")
+	}
+	if i := doc.Find("#c").IndexSelector(":scope, #a"); i != 1 {
+		t.Errorf("expected index 1, got %d", i)
+	}
+}
+
+func TestRelativeInvalid(t *testing.T) {
+	for _, sel := range []string{">", "> li >", "li > :scope", "> > li", "> li["} {
+		func() {
+			defer assertPanic(t)
+			Doc().Find(sel)
+		}()
+	}
+}
+
+func TestSplitSelectorGroups(t *testing.T) {
+	groups := splitSelectorGroups(`a[title="x, y"], :not(b, c), > d\,e`)
+	expected := []string{`a[title="x, y"]`, ` :not(b, c)`, ` > d\,e`}
+	if len(groups) != len(expected) {
+		t.Fatalf("expected %q, got %q", expected, groups)
+	}
+	for i := range groups {
+		if groups[i] != expected[i] {
+			t.Errorf("%d: expected %q, got %q", i, expected[i], groups[i])
+		}
+	}
+}
+
+func assertIds(t *testing.T, sel *Selection, ids ...string) {
+	assertLength(t, sel.Nodes, len(ids))
+	if sel.Length() == len(ids) {
+		assertSelectionIs(t, sel, ids...)
+	}
+}
@@ -0,0 +1,376 @@
+package goquery
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// Specificity returns the specificity of the selector, as defined by
+// Selectors Level 4: a is the number of id selectors, b the number of class,
+// attribute and pseudo-class selectors, and c the number of type selectors
+// and pseudo-elements. The specificity of :is(), :not() and :has() is the one
+// of their most specific argument, :where() has a specificity of zero, and
+// :nth-child() and :nth-last-child() add the one of their "of S" argument.
+// For a selector list, the specificity of the most specific selector is
+// returned. It returns an error if the selector is not syntactically valid,
+// including if it starts with a combinator, as only the arguments of :has()
+// can.
+func Specificity(selector string) (a, b, c int, err error) {
+	spec, err := selectorListSpecificity(selector)
+	return spec[0], spec[1], spec[2], err
+}
+
+// Rule is a CSS rule sorted by SortRules.
+type Rule struct {
+	// Selector is the selector of the rule.
+	Selector string
+	// Order is the position of the rule in the source, such as its index in
+	// the style sheets.
+	Order int
+	// Value is any data attached to the rule, such as its declarations.
+	Value interface{}
+}
+
+// SortRules sorts the rules in the order of the cascade, by increasing
+// specificity and then by increasing source order, so that the last rule
+// wins over the others for an element matching them all. It returns an
+// error, and leaves the rules unsorted, if a selector is not valid.
+func SortRules(rules []Rule) error {
+	specs := make(map[string]specificity, len(rules))
+	for _, r := range rules {
+		if _, ok := specs[r.Selector]; ok {
+			continue
+		}
+		spec, e := selectorListSpecificity(r.Selector)
+		if e != nil {
+			return e
+		}
+		specs[r.Selector] = spec
+	}
+
+	sort.SliceStable(rules, func(i, j int) bool {
+		si, sj := specs[rules[i].Selector], specs[rules[j].Selector]
+		if si != sj {
+			return si.less(sj)
+		}
+		return rules[i].Order < rules[j].Order
+	})
+	return nil
+}
+
+// The specificity of a selector, as its a, b and c components.
+type specificity [3]int
+
+func (s specificity) less(o specificity) bool {
+	for i := range s {
+		if s[i] != o[i] {
+			return s[i] < o[i]
+		}
+	}
+	return false
+}
+
+func (s specificity) add(o specificity) specificity {
+	return specificity{s[0] + o[0], s[1] + o[1], s[2] + o[2]}
+}
+
+// Pseudo-elements that can be written with a single colon, as in CSS 2.
+var legacyPseudoElements = map[string]bool{
+	"before":       true,
+	"after":        true,
+	"first-line":   true,
+	"first-letter": true,
+}
+
+// Returns the specificity of the most specific selector of the list.
+func selectorListSpecificity(selector string) (specificity, error) {
+	var max specificity
+	if e := checkBalanced(selector); e != nil {
+		return max, e
+	}
+	for _, g := range splitSelectorGroups(selector) {
+		if strings.TrimSpace(g) == "" {
+			return max, errors.New("goquery: empty selector in " + selector)
+		}
+		if g = strings.TrimSpace(g); strings.ContainsAny(g[:1], ">+~") {
+			return max, errors.New("goquery: leading combinator outside of :has in " + selector)
+		}
+		spec, e := complexSpecificity(g)
+		if e != nil {
+			return max, e
+		}
+		if max.less(spec) {
+			max = spec
+		}
+	}
+	return max, nil
+}
+
+// Returns the specificity of a complex selector.
+func complexSpecificity(g string) (specificity, error) {
+	var spec specificity
+	parts, _, e := splitCompounds(strings.TrimSpace(g))
+	if e != nil {
+		return spec, e
+	}
+	for _, p := range parts {
+		for _, s := range splitSimpleSelectors(p) {
+			ss, e := simpleSpecificity(s)
+			if e != nil {
+				return spec, e
+			}
+			spec = spec.add(ss)
+		}
+	}
+	return spec, nil
+}
+
+// Returns the specificity of a simple selector.
+func simpleSpecificity(s string) (specificity, error) {
+	invalid := errors.New("goquery: invalid selector " + s)
+	switch {
+	case s[0] == '#':
+		if !isIdent(s[1:]) {
+			return specificity{}, invalid
+		}
+		return specificity{1, 0, 0}, nil
+	case s[0] == '.':
+		if !isIdent(s[1:]) {
+			return specificity{}, invalid
+		}
+		return specificity{0, 1, 0}, nil
+	case s[0] == '[':
+		if len(s) < 3 || s[len(s)-1] != ']' || !isAttrSelector(s[1:len(s)-1]) {
+			return specificity{}, invalid
+		}
+		return specificity{0, 1, 0}, nil
+	case strings.HasPrefix(s, "::"):
+		name, arg, e := splitPseudo(s[2:])
+		if e != nil || !isIdent(name) {
+			return specificity{}, invalid
+		}
+		spec := specificity{0, 0, 1}
+		if name == "slotted" && arg != "" {
+			// The specificity of the compound selector argument is added
+			sub, e := selectorListSpecificity(arg)
+			if e != nil {
+				return specificity{}, e
+			}
+			spec = spec.add(sub)
+		}
+		return spec, nil
+	case s[0] == ':':
+		name, arg, e := splitPseudo(s[1:])
+		if e != nil || !isIdent(name) {
+			return specificity{}, invalid
+		}
+		return pseudoClassSpecificity(name, arg)
+	case !isQualifiedName(s, true):
+		return specificity{}, invalid
+	case s == "*" || strings.HasSuffix(s, "|*"):
+		return specificity{}, nil
+	}
+	return specificity{0, 0, 1}, nil
+}
+
+// Checks if the name is a CSS identifier, such as "item" or "-x", that
+// may contain escaped characters.
+func isIdent(name string) bool {
+	first := strings.TrimPrefix(name, "-")
+	if first == "" || first[0] >= '0' && first[0] <= '9' {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		switch {
+		case name[i] == '\\':
+			if i++; i == len(name) {
+				return false
+			}
+		case !isSelectorNameChar(name[i]):
+			return false
+		}
+	}
+	return true
+}
+
+// Checks if the name is an identifier with an optional namespace prefix,
+// such as "svg|rect", "*|a" or "|a", where the name may also be the
+// universal selector if star is true.
+func isQualifiedName(name string, star bool) bool {
+	if i := strings.IndexByte(name, '|'); i >= 0 {
+		if ns := name[:i]; ns != "" && ns != "*" && !isIdent(ns) {
+			return false
+		}
+		name = name[i+1:]
+	}
+	return star && name == "*" || isIdent(name)
+}
+
+// Checks the content of an attribute selector, such as "href", "rel=up" or
+// "title ^= 'a' i": a qualified name, optionally followed by an operator,
+// an identifier or a string, and a case-sensitivity flag.
+func isAttrSelector(attr string) bool {
+	attr = strings.TrimSpace(attr)
+	i := strings.IndexAny(attr, "=~^$*! \t\n\f\r")
+	if i > 0 && attr[i] == '=' && attr[i-1] == '|' {
+		i--
+	}
+	if i < 0 {
+		return isQualifiedName(attr, false)
+	}
+	if !isQualifiedName(attr[:i], false) {
+		return false
+	}
+
+	rest := strings.TrimSpace(attr[i:])
+	switch {
+	case rest == "":
+		return true
+	case strings.HasPrefix(rest, "="):
+		rest = rest[1:]
+	case len(rest) > 1 && rest[1] == '=' && strings.IndexByte("~|^$*", rest[0]) >= 0:
+		rest = rest[2:]
+	default:
+		return false
+	}
+
+	rest = strings.TrimSpace(rest)
+	if rest == "" {
+		return false
+	}
+	if q := rest[0]; q == '"' || q == '\'' {
+		end := 1
+		for ; end < len(rest) && rest[end] != q; end++ {
+			if rest[end] == '\\' {
+				end++
+			}
+		}
+		if end >= len(rest) {
+			return false
+		}
+		rest = rest[end+1:]
+	} else {
+		end := strings.IndexAny(rest, " \t\n\f\r")
+		if end < 0 {
+			end = len(rest)
+		}
+		if !isIdent(rest[:end]) {
+			return false
+		}
+		rest = rest[end:]
+	}
+	flag := strings.ToLower(strings.TrimSpace(rest))
+	return flag == "" || flag == "i" || flag == "s"
+}
+
+// Returns the specificity of a pseudo-class, or of a legacy pseudo-element.
+func pseudoClassSpecificity(name, arg string) (specificity, error) {
+	switch name {
+	case "is", "matches", "any", "not", "has":
+		return relativeListSpecificity(arg)
+	case "where":
+		_, e := relativeListSpecificity(arg)
+		return specificity{}, e
+	case "nth-child", "nth-last-child":
+		spec := specificity{0, 1, 0}
+		if i := indexOfKeyword(arg, "of"); i >= 0 {
+			sub, e := selectorListSpecificity(arg[i+len("of"):])
+			if e != nil {
+				return specificity{}, e
+			}
+			spec = spec.add(sub)
+		}
+		return spec, nil
+	}
+	if legacyPseudoElements[name] {
+		return specificity{0, 0, 1}, nil
+	}
+	return specificity{0, 1, 0}, nil
+}
+
+// Returns the specificity of the most specific selector of the list, which
+// may contain relative selectors, as the argument of :has.
+func relativeListSpecificity(list string) (specificity, error) {
+	var max specificity
+	if strings.TrimSpace(list) == "" {
+		return max, errors.New("goquery: empty selector list")
+	}
+	for _, g := range splitSelectorGroups(list) {
+		g = strings.TrimSpace(g)
+		if g != "" && strings.ContainsAny(g[:1], ">+~") {
+			g = g[1:]
+		}
+		if strings.TrimSpace(g) == "" {
+			return max, errors.New("goquery: empty selector in " + list)
+		}
+		spec, e := complexSpecificity(g)
+		if e != nil {
+			return max, e
+		}
+		if max.less(spec) {
+			max = spec
+		}
+	}
+	return max, nil
+}
+
+// Splits the pseudo-class or pseudo-element, without its colons, in its
+// lowercase name and its argument.
+func splitPseudo(s string) (name, arg string, err error) {
+	i := strings.IndexByte(s, '(')
+	if i < 0 {
+		return strings.ToLower(s), "", nil
+	}
+	if closingParen(s, i) != len(s)-1 {
+		return "", "", errors.New("goquery: invalid pseudo-class " + s)
+	}
+	return strings.ToLower(s[:i]), s[i+1 : len(s)-1], nil
+}
+
+// Returns the index of the keyword, delimited by whitespace, at the top level
+// of the argument, or -1.
+func indexOfKeyword(arg, kw string) int {
+	idx := -1
+	scanSelector(arg, func(i int, c byte, top bool) {
+		if idx < 0 && top && i > 0 && isASCIIWhitespace(arg[i-1]) &&
+			strings.HasPrefix(strings.ToLower(arg[i:]), kw) &&
+			i+len(kw) < len(arg) && isASCIIWhitespace(arg[i+len(kw)]) {
+			idx = i
+		}
+	})
+	return idx
+}
+
+// Checks that the brackets, parentheses and strings of the selector are
+// closed.
+func checkBalanced(selector string) error {
+	var stack []byte
+	var quote byte
+	for i := 0; i < len(selector); i++ {
+		c := selector[i]
+		switch {
+		case c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(':
+			stack = append(stack, ')')
+		case c == '[':
+			stack = append(stack, ']')
+		case c == ')' || c == ']':
+			if len(stack) == 0 || stack[len(stack)-1] != c {
+				return errors.New("goquery: unbalanced " + string(c) + " in " + selector)
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+	if quote != 0 || len(stack) > 0 {
+		return errors.New("goquery: unclosed string, bracket or parenthesis in " + selector)
+	}
+	return nil
+}
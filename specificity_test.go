@@ -0,0 +1,78 @@
+package goquery
+
+import (
+	"testing"
+)
+
+func TestSpecificity(t *testing.T) {
+	cases := []struct {
+		sel     string
+		a, b, c int
+	}{
+		{"*", 0, 0, 0},
+		{"li", 0, 0, 1},
+		{"ul li", 0, 0, 2},
+		{"ul ol+li", 0, 0, 3},
+		{"h1 + *[rel=up]", 0, 1, 1},
+		{"ul ol li.red", 0, 1, 3},
+		{"li.red.level", 0, 2, 1},
+		{"#x34y", 1, 0, 0},
+		{"#s12:not(FOO)", 1, 0, 1},
+		{".foo :is(.bar, #baz)", 1, 1, 0},
+		{"div:where(#a, .b) p", 0, 0, 2},
+		{"a:has(> img.logo)", 0, 1, 2},
+		{"li:nth-child(2n+1 of .item, #main)", 1, 1, 1},
+		{"li:nth-child(2n + 1)", 0, 1, 1},
+		{"p::first-line", 0, 0, 2},
+		{"p:before", 0, 0, 2},
+		{"svg|rect:hover", 0, 1, 1},
+		{"a[href*=\"(x\"]:not([title]):visited", 0, 3, 1},
+		{"div, #a, .b.c", 1, 0, 0},
+		{":scope > li", 0, 1, 1},
+		{"*|p.-a_b#\\31x", 1, 1, 1},
+		{"[lang|=en][title^='a' i][data-x=\"y\"]", 0, 3, 0},
+		{"|a[xml|lang]", 0, 1, 1},
+	}
+	for _, c := range cases {
+		a, b, cc, e := Specificity(c.sel)
+		if e != nil {
+			t.Errorf("%s: %s", c.sel, e)
+			continue
+		}
+		if a != c.a || b != c.b || cc != c.c {
+			t.Errorf("%s: expected (%d, %d, %d), got (%d, %d, %d)", c.sel, c.a, c.b, c.c, a, b, cc)
+		}
+	}
+
+	for _, sel := range []string{"", "a,", "div >", ".", "#", "a:not(", "a[b", "a)", ":is()", "p:", "a:not(.b))",
+		"a!b", "12abc", "#1a", ".a!b", "p.-", "a|b|c", "p:1st", "[a!=b]", "[a=b c]", "[1a]", "[a=]", ":not(a!b)",
+		"> a", "a, + b", "~ li:scope"} {
+		if _, _, _, e := Specificity(sel); e == nil {
+			t.Errorf("%q: expected an error", sel)
+		}
+	}
+}
+
+func TestSortRules(t *testing.T) {
+	rules := []Rule{
+		{Selector: "#main p", Order: 0},
+		{Selector: "p.intro", Order: 1},
+		{Selector: "p", Order: 2},
+		{Selector: "div p", Order: 3},
+		{Selector: "p:where(.x)", Order: 4},
+		{Selector: "p.note", Order: 5},
+	}
+	if e := SortRules(rules); e != nil {
+		t.Fatal(e)
+	}
+	expected := []int{2, 4, 3, 1, 5, 0}
+	for i, r := range rules {
+		if r.Order != expected[i] {
+			t.Errorf("%d: expected rule %d, got %d (%s)", i, expected[i], r.Order, r.Selector)
+		}
+	}
+
+	if e := SortRules([]Rule{{Selector: "p"}, {Selector: "p["}}); e == nil {
+		t.Error("expected an error")
+	}
+}
@@ -0,0 +1,297 @@
+package goquery
+
+import (
+	"io"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Streamer parses an HTML document incrementally and calls handlers for the
+// elements matching the registered selectors, without holding the whole
+// document tree in memory. It is meant for documents too big to be loaded
+// with NewDocumentFromReader.
+//
+// The document is tokenized with html.Tokenizer, and only the following
+// nodes are kept in memory:
+//
+// 1) The chain of currently open elements (the ancestors of the current
+// position in the document), with their attributes but without their
+// already closed children.
+//
+// 2) The subtree of each element matched by a handler, until its end tag is
+// seen. The handler is then called with a Selection of that element, after
+// which the element is removed from the tree, unless it is itself within
+// another matched element.
+//
+// Because of this, selectors are matched when the start tag is seen, against
+// the element and its ancestors only. Combinators other than the descendant
+// and child ones, positional pseudo-classes (such as :nth-child) and
+// pseudo-classes that depend on the element's content (such as :contains or
+// :empty) are not supported and may give unexpected results. Also, the HTML5
+// tree construction algorithm is only partly applied: end tags close the
+// matching open element, void elements (such as <br> or <img>) are handled,
+// and the elements whose end tag may be omitted (p, li, dt, dd, tr, td, th,
+// thead, tbody, tfoot, option and optgroup) are implicitly closed by the
+// start tags that close them in the HTML spec, but no element is implicitly
+// opened (such as <tbody> in a table) and misnested tags are not fixed.
+type Streamer struct {
+	handlers []streamHandler
+}
+
+type streamHandler struct {
+	m Matcher
+	f func(*Selection) error
+}
+
+// NewStreamer returns a Streamer with no handlers registered.
+func NewStreamer() *Streamer {
+	return &Streamer{}
+}
+
+// Handle registers a function to be called with each element matching the
+// selector, once its end tag is seen. It returns the Streamer so that calls
+// can be chained.
+func (st *Streamer) Handle(selector string, f func(*Selection) error) *Streamer {
+	return st.HandleMatcher(compileMatcher(selector, nil), f)
+}
+
+// HandleMatcher registers a function to be called with each element matching
+// the matcher, once its end tag is seen. It returns the Streamer so that
+// calls can be chained.
+func (st *Streamer) HandleMatcher(m Matcher, f func(*Selection) error) *Streamer {
+	st.handlers = append(st.handlers, streamHandler{m, f})
+	return st
+}
+
+// Stream reads and tokenizes the HTML document from the reader, calling the
+// registered handlers as matching elements are completed. If a handler
+// returns an error, the streaming stops and that error is returned. It
+// returns nil once the end of the reader is reached, or the reader's error
+// if it is not io.EOF.
+//
+// The Selection passed to the handlers belongs to a Document holding the
+// partial tree described on Streamer, so methods that look up the document
+// (such as Add or IndexSelector) only see that partial tree. The element is
+// still attached to its ancestors during the call, but is detached after
+// it, so the Selection can be kept and used after the handler returns.
+func (st *Streamer) Stream(r io.Reader) error {
+	z := html.NewTokenizer(r)
+	root := &html.Node{Type: html.DocumentNode}
+	doc := newDocument(root, nil)
+
+	// The open elements, from the outermost to the current one
+	var stack []*streamElement
+	cur := func() *html.Node {
+		if len(stack) > 0 {
+			return stack[len(stack)-1].n
+		}
+		return root
+	}
+	capturing := func() bool {
+		return len(stack) > 0 && stack[len(stack)-1].keep
+	}
+
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			if e := z.Err(); e != io.EOF {
+				return e
+			}
+			// Elements still open at the end of the document are closed,
+			// as the html parser does.
+			_, e := st.closeElements(doc, stack, 0)
+			return e
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tok := z.Token()
+			// Close the elements whose end tag is implied by this start tag
+			for _, i := range impliedEndTags(stack, tok.Data) {
+				var e error
+				if stack, e = st.closeElements(doc, stack, i); e != nil {
+					return e
+				}
+			}
+
+			n := &html.Node{
+				Type:     html.ElementNode,
+				Data:     tok.Data,
+				DataAtom: tok.DataAtom,
+				Attr:     tok.Attr,
+			}
+			cur().AppendChild(n)
+			el := &streamElement{n: n, keep: capturing()}
+			for i, h := range st.handlers {
+				if h.m.Match(n) {
+					el.matches = append(el.matches, i)
+					el.keep = true
+				}
+			}
+			stack = append(stack, el)
+			if tt == html.SelfClosingTagToken || isVoidElement(tok.DataAtom) {
+				var e error
+				if stack, e = st.closeElements(doc, stack, len(stack)-1); e != nil {
+					return e
+				}
+			}
+
+		case html.EndTagToken:
+			tok := z.Token()
+			// Close the closest open element with the same name, ignore the
+			// end tag if there is none.
+			for i := len(stack) - 1; i >= 0; i-- {
+				if stack[i].n.Data == tok.Data {
+					var e error
+					if stack, e = st.closeElements(doc, stack, i); e != nil {
+						return e
+					}
+					break
+				}
+			}
+
+		case html.TextToken, html.CommentToken:
+			// Text and comments are only relevant within a matched element
+			if capturing() {
+				tok := z.Token()
+				t := html.TextNode
+				if tt == html.CommentToken {
+					t = html.CommentNode
+				}
+				cur().AppendChild(&html.Node{Type: t, Data: tok.Data})
+			}
+		}
+	}
+}
+
+// An open element, the indices of the handlers it matched, and whether its
+// subtree is kept.
+type streamElement struct {
+	n       *html.Node
+	matches []int
+	keep    bool
+}
+
+// Closes the open elements from the top of the stack down to (and including)
+// the element at index i, calling the handlers of the matched ones, and
+// returns the new stack.
+func (st *Streamer) closeElements(doc *Document, stack []*streamElement, i int) ([]*streamElement, error) {
+	for j := len(stack) - 1; j >= i; j-- {
+		el := stack[j]
+		stack = stack[:j]
+
+		sel := newSingleSelection(el.n, doc)
+		for _, h := range el.matches {
+			if e := st.handlers[h].f(sel); e != nil {
+				return stack, e
+			}
+		}
+		// Keep the element only if it is part of an enclosing matched
+		// element's subtree.
+		if len(stack) == 0 || !stack[len(stack)-1].keep {
+			if el.n.Parent != nil {
+				el.n.Parent.RemoveChild(el.n)
+			}
+		}
+	}
+	return stack, nil
+}
+
+// Returns a set of element names.
+func nameSet(names ...string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	return set
+}
+
+var (
+	// The start tags closing an open p element, per the HTML spec.
+	pClosers = nameSet("address", "article", "aside", "blockquote", "center",
+		"details", "dialog", "dir", "div", "dl", "dd", "dt", "fieldset",
+		"figcaption", "figure", "footer", "form", "h1", "h2", "h3", "h4", "h5",
+		"h6", "header", "hgroup", "hr", "li", "listing", "main", "menu", "nav",
+		"ol", "p", "plaintext", "pre", "search", "section", "summary", "table",
+		"ul", "xmp")
+
+	// The elements limiting the search of an element to close, as the
+	// scopes of the HTML spec.
+	buttonScope   = nameSet("html", "table", "td", "th", "caption", "template", "marquee", "object", "applet", "button")
+	listItemScope = nameSet("html", "table", "td", "th", "caption", "template", "marquee", "object", "applet", "ol", "ul")
+	defListScope  = nameSet("html", "table", "td", "th", "caption", "template", "marquee", "object", "applet", "dl")
+	tableScope    = nameSet("html", "table", "template")
+	rowScope      = nameSet("html", "table", "template", "thead", "tbody", "tfoot")
+	cellScope     = nameSet("html", "table", "template", "tr")
+	selectScope   = nameSet("html", "select", "datalist", "template")
+
+	// The elements implicitly closed by the start tags.
+	pElements        = nameSet("p")
+	listItemElements = nameSet("li")
+	defListElements  = nameSet("dd", "dt")
+	sectionElements  = nameSet("thead", "tbody", "tfoot")
+	rowElements      = nameSet("tr")
+	cellElements     = nameSet("td", "th")
+	optionElements   = nameSet("option")
+	optgroupElements = nameSet("optgroup")
+)
+
+// Returns the indices in the stack of the open elements implicitly closed by
+// the start tag, from the innermost. Closing an element closes the elements
+// above it in the stack as well.
+func impliedEndTags(stack []*streamElement, tag string) (indices []int) {
+	add := func(names, scope map[string]bool) {
+		if i := openElementInScope(stack, names, scope); i >= 0 {
+			indices = append(indices, i)
+			stack = stack[:i]
+		}
+	}
+
+	if pClosers[tag] {
+		add(pElements, buttonScope)
+	}
+	switch tag {
+	case "li":
+		add(listItemElements, listItemScope)
+	case "dd", "dt":
+		add(defListElements, defListScope)
+	case "thead", "tbody", "tfoot":
+		add(sectionElements, tableScope)
+	case "tr":
+		add(rowElements, rowScope)
+	case "td", "th":
+		add(cellElements, cellScope)
+	case "option":
+		add(optionElements, selectScope)
+	case "optgroup":
+		add(optionElements, selectScope)
+		add(optgroupElements, selectScope)
+	}
+	return
+}
+
+// Returns the index of the innermost open element with one of the names,
+// searching down the stack until an element of the scope, or -1.
+func openElementInScope(stack []*streamElement, names, scope map[string]bool) int {
+	for i := len(stack) - 1; i >= 0; i-- {
+		name := stack[i].n.Data
+		if names[name] {
+			return i
+		}
+		if scope[name] {
+			break
+		}
+	}
+	return -1
+}
+
+// Checks if the element has no content and no end tag, per the HTML spec.
+func isVoidElement(a atom.Atom) bool {
+	switch a {
+	case atom.Area, atom.Base, atom.Br, atom.Col, atom.Embed, atom.Hr, atom.Img,
+		atom.Input, atom.Keygen, atom.Link, atom.Meta, atom.Param, atom.Source,
+		atom.Track, atom.Wbr:
+		return true
+	}
+	return false
+}
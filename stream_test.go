@@ -0,0 +1,208 @@
+package goquery
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestStream(t *testing.T) {
+	var texts []string
+
+	src := `<html><body><ul><li class="a">One</li><li>Two <b>bold</b></li><li class="a">Three<br>Four</li></ul></body></html>`
+	e := NewStreamer().Handle("li.a", func(s *Selection) error {
+		texts = append(texts, s.Text())
+		return nil
+	}).Stream(strings.NewReader(src))
+	if e != nil {
+		t.Fatalf("Expected no error, got %s.", e)
+	}
+	if len(texts) != 2 || texts[0] != "One" || texts[1] != "ThreeFour" {
+		t.Errorf("Expected texts [One ThreeFour], got %v.", texts)
+	}
+}
+
+func TestStreamAncestors(t *testing.T) {
+	var cnt int
+
+	src := `<div id="main"><p>In</p></div><div><p>Out</p></div>`
+	e := NewStreamer().Handle("#main > p", func(s *Selection) error {
+		cnt++
+		if s.Text() != "In" {
+			t.Errorf("Expected text In, got %s.", s.Text())
+		}
+		if s.Closest("#main").Length() != 1 {
+			t.Error("Expected #main ancestor to be available in the handler.")
+		}
+		return nil
+	}).Stream(strings.NewReader(src))
+	if e != nil {
+		t.Fatalf("Expected no error, got %s.", e)
+	}
+	if cnt != 1 {
+		t.Errorf("Expected handler to be called once, got %d times.", cnt)
+	}
+}
+
+func TestStreamNested(t *testing.T) {
+	var order []string
+
+	src := `<section><article><h1>Title</h1><p>Body</p></article></section>`
+	e := NewStreamer().Handle("h1", func(s *Selection) error {
+		order = append(order, "h1")
+		return nil
+	}).Handle("article", func(s *Selection) error {
+		order = append(order, "article")
+		// The inner match is kept in the enclosing match's subtree
+		assertLength(t, s.Find("h1, p").Nodes, 2)
+		return nil
+	}).Stream(strings.NewReader(src))
+	if e != nil {
+		t.Fatalf("Expected no error, got %s.", e)
+	}
+	if strings.Join(order, ",") != "h1,article" {
+		t.Errorf("Expected handlers to be called in order h1,article, got %v.", order)
+	}
+}
+
+func TestStreamDiscards(t *testing.T) {
+	var kept []*Selection
+
+	src := `<ul><li>1</li><li>2</li><li>3</li></ul>`
+	e := NewStreamer().Handle("li", func(s *Selection) error {
+		kept = append(kept, s)
+		// Previous matches have been detached
+		assertLength(t, s.Parent().Children().Nodes, 1)
+		return nil
+	}).Stream(strings.NewReader(src))
+	if e != nil {
+		t.Fatalf("Expected no error, got %s.", e)
+	}
+	if len(kept) != 3 {
+		t.Fatalf("Expected 3 matches, got %d.", len(kept))
+	}
+	for i, s := range kept {
+		if s.Get(0).Parent != nil {
+			t.Errorf("Expected match %d to be detached after the handler.", i)
+		}
+	}
+}
+
+func TestStreamUnclosed(t *testing.T) {
+	var cnt int
+
+	src := `<div class="x"><span>unclosed`
+	e := NewStreamer().Handle(".x", func(s *Selection) error {
+		cnt++
+		if s.Text() != "unclosed" {
+			t.Errorf("Expected text unclosed, got %s.", s.Text())
+		}
+		return nil
+	}).Stream(strings.NewReader(src))
+	if e != nil {
+		t.Fatalf("Expected no error, got %s.", e)
+	}
+	if cnt != 1 {
+		t.Errorf("Expected handler to be called once, got %d times.", cnt)
+	}
+}
+
+func TestStreamHandlerError(t *testing.T) {
+	errStop := errors.New("stop")
+
+	var cnt int
+	e := NewStreamer().Handle("li", func(s *Selection) error {
+		cnt++
+		return errStop
+	}).Stream(strings.NewReader(`<ul><li>1</li><li>2</li></ul>`))
+	if e != errStop {
+		t.Errorf("Expected error %v, got %v.", errStop, e)
+	}
+	if cnt != 1 {
+		t.Errorf("Expected handler to be called once, got %d times.", cnt)
+	}
+}
+
+func TestStreamInvalidSelector(t *testing.T) {
+	defer assertPanic(t)
+	NewStreamer().Handle(":+ ^", nil)
+}
+
+func TestStreamDocument(t *testing.T) {
+	f, e := os.Open("./testdata/page.html")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer f.Close()
+
+	var cnt int
+	e = NewStreamer().Handle("div.pvk-content", func(s *Selection) error {
+		cnt++
+		return nil
+	}).Stream(f)
+	if e != nil {
+		t.Fatalf("Expected no error, got %s.", e)
+	}
+	if exp := Doc().Find("div.pvk-content").Length(); cnt != exp {
+		t.Errorf("Expected handler to be called %d times, got %d times.", exp, cnt)
+	}
+}
+
+func TestStreamImpliedEndTags(t *testing.T) {
+	var ps, items, cells []string
+	var maxDepth int
+
+	src := `<div><p>a<p>b<div>c</div></div>` +
+		`<ul><li>d<li>e<ul><li>f</ul><li>g</ul>` +
+		`<table><tr><td>1<td>2<tr><th>3</table>` +
+		`<select><option>x<optgroup><option>y<option>z</select>`
+	e := NewStreamer().Handle("div > p", func(s *Selection) error {
+		ps = append(ps, s.Text())
+		return nil
+	}).Handle("ul > li", func(s *Selection) error {
+		items = append(items, s.Text())
+		return nil
+	}).Handle("tr > td, tr > th, optgroup > option", func(s *Selection) error {
+		cells = append(cells, s.Text())
+		if d := s.Parents().Length(); d > maxDepth {
+			maxDepth = d
+		}
+		return nil
+	}).Stream(strings.NewReader(src))
+	if e != nil {
+		t.Fatalf("Expected no error, got %s.", e)
+	}
+
+	if strings.Join(ps, ",") != "a,b" {
+		t.Errorf("Expected paragraphs [a b], got %v.", ps)
+	}
+	if strings.Join(items, ",") != "d,f,ef,g" {
+		t.Errorf("Expected items [d f ef g], got %v.", items)
+	}
+	if strings.Join(cells, ",") != "1,2,3,y,z" {
+		t.Errorf("Expected cells [1 2 3 y z], got %v.", cells)
+	}
+	if maxDepth != 2 {
+		t.Errorf("Expected the cells to be at depth 2, got %d.", maxDepth)
+	}
+}
+
+func TestStreamImpliedEndTagsDepth(t *testing.T) {
+	var cnt int
+
+	src := `<div>` + strings.Repeat(`<p>text`, 1000) + `</div>`
+	e := NewStreamer().Handle("p", func(s *Selection) error {
+		cnt++
+		if d := s.Parents().Length(); d != 1 {
+			t.Fatalf("Expected the paragraph to be a child of the div, got depth %d.", d)
+		}
+		return nil
+	}).Stream(strings.NewReader(src))
+	if e != nil {
+		t.Fatalf("Expected no error, got %s.", e)
+	}
+	if cnt != 1000 {
+		t.Errorf("Expected 1000 paragraphs, got %d.", cnt)
+	}
+}
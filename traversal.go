@@ -1,7 +1,6 @@
 package goquery
 
 import (
-	"code.google.com/p/cascadia"
 	"golang.org/x/net/html"
 )
 
@@ -22,9 +21,17 @@ const (
 
 // Find gets the descendants of each element in the current set of matched
 // elements, filtered by a selector. It returns a new Selection object
-// containing these matched elements.
+// containing these matched elements. All matches are collected, as the Nodes
+// of the Selection are exposed and can't be computed lazily, so
+// Find(selector).First() or Find(selector).Eq(i) walk the whole tree: if only
+// the first matches are needed, use FindFirst or FindN, which stop walking
+// the tree once enough matches are found.
+//
+// The selector may be relative to each element, such as "> li" for the
+// children, or "+ p" and "~ p" for the following siblings (which are then
+// searched as well).
 func (s *Selection) Find(selector string) *Selection {
-	return pushStack(s, findWithMatcher(s.Nodes, cascadia.MustCompile(selector)))
+	return pushStack(s, findWithMatcher(s.Nodes, compileMatcher(selector, s)))
 }
 
 // FindMatcher gets the descendants of each element in the current set of matched
@@ -34,6 +41,52 @@ func (s *Selection) FindMatcher(m Matcher) *Selection {
 	return pushStack(s, findWithMatcher(s.Nodes, m))
 }
 
+// FindFirst gets the first descendant of the elements in the current set of
+// matched elements that matches the selector. Unlike Find(selector).First(),
+// the tree walk stops as soon as the match is found. It returns a new
+// Selection object containing the matched element, if any.
+func (s *Selection) FindFirst(selector string) *Selection {
+	return s.FindNMatcher(compileMatcher(selector, s), 1)
+}
+
+// FindFirstMatcher gets the first descendant of the elements in the current
+// set of matched elements that matches the matcher. The tree walk stops as
+// soon as the match is found. It returns a new Selection object containing
+// the matched element, if any.
+func (s *Selection) FindFirstMatcher(m Matcher) *Selection {
+	return s.FindNMatcher(m, 1)
+}
+
+// FindN gets at most n descendants of the elements in the current set of
+// matched elements that match the selector, in the same order as Find. The
+// tree walk stops as soon as n matches are found. It returns a new Selection
+// object containing the matched elements.
+func (s *Selection) FindN(selector string, n int) *Selection {
+	return s.FindNMatcher(compileMatcher(selector, s), n)
+}
+
+// FindNMatcher gets at most n descendants of the elements in the current set
+// of matched elements that match the matcher, in the same order as
+// FindMatcher. The tree walk stops as soon as n matches are found. It returns
+// a new Selection object containing the matched elements.
+func (s *Selection) FindNMatcher(m Matcher, n int) *Selection {
+	return pushStack(s, findNWithMatcher(s.Nodes, m, n))
+}
+
+// Exists returns true if at least one descendant of the elements in the
+// current set of matched elements matches the selector. The tree walk stops
+// at the first match.
+func (s *Selection) Exists(selector string) bool {
+	return s.ExistsMatcher(compileMatcher(selector, s))
+}
+
+// ExistsMatcher returns true if at least one descendant of the elements in
+// the current set of matched elements matches the matcher. The tree walk
+// stops at the first match.
+func (s *Selection) ExistsMatcher(m Matcher) bool {
+	return len(findNWithMatcher(s.Nodes, m, 1)) > 0
+}
+
 // FindSelection gets the descendants of each element in the current
 // Selection, filtered by a Selection. It returns a new Selection object
 // containing these matched elements.
@@ -93,7 +146,7 @@ func (s *Selection) Children() *Selection {
 // filtered by the specified selector. It returns a new
 // Selection object containing these elements.
 func (s *Selection) ChildrenFiltered(selector string) *Selection {
-	return filterAndPush(s, getChildrenNodes(s.Nodes, siblingAll), cascadia.MustCompile(selector))
+	return filterAndPush(s, getChildrenNodes(s.Nodes, siblingAll), compileMatcher(selector, s))
 }
 
 // ChildrenMatcher gets the child elements of each element in the Selection,
@@ -112,7 +165,7 @@ func (s *Selection) Parent() *Selection {
 // ParentFiltered gets the parent of each element in the Selection filtered by a
 // selector. It returns a new Selection object containing the matched elements.
 func (s *Selection) ParentFiltered(selector string) *Selection {
-	return filterAndPush(s, getParentNodes(s.Nodes), cascadia.MustCompile(selector))
+	return filterAndPush(s, getParentNodes(s.Nodes), compileMatcher(selector, s))
 }
 
 // ParentMatcher gets the parent of each element in the Selection filtered by a
@@ -124,7 +177,7 @@ func (s *Selection) ParentMatcher(m Matcher) *Selection {
 // Closest gets the first element that matches the selector by testing the
 // element itself and traversing up through its ancestors in the DOM tree.
 func (s *Selection) Closest(selector string) *Selection {
-	cs := cascadia.MustCompile(selector)
+	cs := compileMatcher(selector, s)
 	return s.ClosestMatcher(cs)
 }
 
@@ -177,7 +230,7 @@ func (s *Selection) Parents() *Selection {
 // ParentsFiltered gets the ancestors of each element in the current
 // Selection. It returns a new Selection object with the matched elements.
 func (s *Selection) ParentsFiltered(selector string) *Selection {
-	return filterAndPush(s, getParentsNodes(s.Nodes, nil, nil), cascadia.MustCompile(selector))
+	return filterAndPush(s, getParentsNodes(s.Nodes, nil, nil), compileMatcher(selector, s))
 }
 
 // ParentsMatcher gets the ancestors of each element in the current
@@ -190,7 +243,7 @@ func (s *Selection) ParentsMatcher(m Matcher) *Selection {
 // not including the element matched by the selector. It returns a new Selection
 // object containing the matched elements.
 func (s *Selection) ParentsUntil(selector string) *Selection {
-	return pushStack(s, getParentsNodes(s.Nodes, cascadia.MustCompile(selector), nil))
+	return pushStack(s, getParentsNodes(s.Nodes, compileMatcher(selector, s), nil))
 }
 
 // ParentsUntilMatcher gets the ancestors of each element in the Selection, up to but
@@ -221,7 +274,7 @@ func (s *Selection) ParentsUntilNodes(nodes ...*html.Node) *Selection {
 // results based on a selector string. It returns a new Selection
 // object containing the matched elements.
 func (s *Selection) ParentsFilteredUntil(filterSelector, untilSelector string) *Selection {
-	return filterAndPush(s, getParentsNodes(s.Nodes, cascadia.MustCompile(untilSelector), nil), cascadia.MustCompile(filterSelector))
+	return filterAndPush(s, getParentsNodes(s.Nodes, compileMatcher(untilSelector, s), nil), compileMatcher(filterSelector, s))
 }
 
 // ParentsFilteredUntilMatcher is like ParentsUntilMatcher, with the option to filter the
@@ -234,7 +287,7 @@ func (s *Selection) ParentsFilteredUntilMatcher(filter, until Matcher) *Selectio
 // option to filter the results based on a selector string. It returns a new
 // Selection object containing the matched elements.
 func (s *Selection) ParentsFilteredUntilSelection(filterSelector string, sel *Selection) *Selection {
-	return s.ParentsMatcherUntilSelection(cascadia.MustCompile(filterSelector), sel)
+	return s.ParentsMatcherUntilSelection(compileMatcher(filterSelector, s), sel)
 }
 
 // ParentsMatcherUntilSelection is like ParentsUntilSelection, with the
@@ -251,7 +304,7 @@ func (s *Selection) ParentsMatcherUntilSelection(filter Matcher, sel *Selection)
 // option to filter the results based on a selector string. It returns a new
 // Selection object containing the matched elements.
 func (s *Selection) ParentsFilteredUntilNodes(filterSelector string, nodes ...*html.Node) *Selection {
-	return filterAndPush(s, getParentsNodes(s.Nodes, nil, nodes), cascadia.MustCompile(filterSelector))
+	return filterAndPush(s, getParentsNodes(s.Nodes, nil, nodes), compileMatcher(filterSelector, s))
 }
 
 // ParentsMatcherUntilNodes is like ParentsUntilNodes, with the
@@ -271,7 +324,7 @@ func (s *Selection) Siblings() *Selection {
 // filtered by a selector. It returns a new Selection object containing the
 // matched elements.
 func (s *Selection) SiblingsFiltered(selector string) *Selection {
-	return filterAndPush(s, getSiblingNodes(s.Nodes, siblingAll, nil, nil), cascadia.MustCompile(selector))
+	return filterAndPush(s, getSiblingNodes(s.Nodes, siblingAll, nil, nil), compileMatcher(selector, s))
 }
 
 // SiblingsMatcher gets the siblings of each element in the Selection
@@ -291,7 +344,7 @@ func (s *Selection) Next() *Selection {
 // Selection filtered by a selector. It returns a new Selection object
 // containing the matched elements.
 func (s *Selection) NextFiltered(selector string) *Selection {
-	return filterAndPush(s, getSiblingNodes(s.Nodes, siblingNext, nil, nil), cascadia.MustCompile(selector))
+	return filterAndPush(s, getSiblingNodes(s.Nodes, siblingNext, nil, nil), compileMatcher(selector, s))
 }
 
 // NextMatcher gets the immediately following sibling of each element in the
@@ -311,7 +364,7 @@ func (s *Selection) NextAll() *Selection {
 // Selection filtered by a selector. It returns a new Selection object
 // containing the matched elements.
 func (s *Selection) NextAllFiltered(selector string) *Selection {
-	return filterAndPush(s, getSiblingNodes(s.Nodes, siblingNextAll, nil, nil), cascadia.MustCompile(selector))
+	return filterAndPush(s, getSiblingNodes(s.Nodes, siblingNextAll, nil, nil), compileMatcher(selector, s))
 }
 
 // NextAllMatcher gets all the following siblings of each element in the
@@ -331,7 +384,7 @@ func (s *Selection) Prev() *Selection {
 // Selection filtered by a selector. It returns a new Selection object
 // containing the matched elements.
 func (s *Selection) PrevFiltered(selector string) *Selection {
-	return filterAndPush(s, getSiblingNodes(s.Nodes, siblingPrev, nil, nil), cascadia.MustCompile(selector))
+	return filterAndPush(s, getSiblingNodes(s.Nodes, siblingPrev, nil, nil), compileMatcher(selector, s))
 }
 
 // PrevMatcher gets the immediately preceding sibling of each element in the
@@ -351,7 +404,7 @@ func (s *Selection) PrevAll() *Selection {
 // Selection filtered by a selector. It returns a new Selection object
 // containing the matched elements.
 func (s *Selection) PrevAllFiltered(selector string) *Selection {
-	return filterAndPush(s, getSiblingNodes(s.Nodes, siblingPrevAll, nil, nil), cascadia.MustCompile(selector))
+	return filterAndPush(s, getSiblingNodes(s.Nodes, siblingPrevAll, nil, nil), compileMatcher(selector, s))
 }
 
 // PrevAllMatcher gets all the preceding siblings of each element in the
@@ -366,7 +419,7 @@ func (s *Selection) PrevAllMatcher(m Matcher) *Selection {
 // object containing the matched elements.
 func (s *Selection) NextUntil(selector string) *Selection {
 	return pushStack(s, getSiblingNodes(s.Nodes, siblingNextUntil,
-		cascadia.MustCompile(selector), nil))
+		compileMatcher(selector, s), nil))
 }
 
 // NextUntilMatcher gets all following siblings of each element up to but not
@@ -400,7 +453,7 @@ func (s *Selection) NextUntilNodes(nodes ...*html.Node) *Selection {
 // object containing the matched elements.
 func (s *Selection) PrevUntil(selector string) *Selection {
 	return pushStack(s, getSiblingNodes(s.Nodes, siblingPrevUntil,
-		cascadia.MustCompile(selector), nil))
+		compileMatcher(selector, s), nil))
 }
 
 // PrevUntilMatcher gets all preceding siblings of each element up to but not
@@ -434,7 +487,7 @@ func (s *Selection) PrevUntilNodes(nodes ...*html.Node) *Selection {
 // It returns a new Selection object containing the matched elements.
 func (s *Selection) NextFilteredUntil(filterSelector, untilSelector string) *Selection {
 	return filterAndPush(s, getSiblingNodes(s.Nodes, siblingNextUntil,
-		cascadia.MustCompile(untilSelector), nil), cascadia.MustCompile(filterSelector))
+		compileMatcher(untilSelector, s), nil), compileMatcher(filterSelector, s))
 }
 
 // NextFilteredUntilMatcher is like NextUntilMatcher, with the option to filter
@@ -449,7 +502,7 @@ func (s *Selection) NextFilteredUntilMatcher(filter, until Matcher) *Selection {
 // option to filter the results based on a selector string. It returns a new
 // Selection object containing the matched elements.
 func (s *Selection) NextFilteredUntilSelection(filterSelector string, sel *Selection) *Selection {
-	return s.NextMatcherUntilSelection(cascadia.MustCompile(filterSelector), sel)
+	return s.NextMatcherUntilSelection(compileMatcher(filterSelector, s), sel)
 }
 
 // NextMatcherUntilSelection is like NextUntilSelection, with the
@@ -467,7 +520,7 @@ func (s *Selection) NextMatcherUntilSelection(filter Matcher, sel *Selection) *S
 // Selection object containing the matched elements.
 func (s *Selection) NextFilteredUntilNodes(filterSelector string, nodes ...*html.Node) *Selection {
 	return filterAndPush(s, getSiblingNodes(s.Nodes, siblingNextUntil,
-		nil, nodes), cascadia.MustCompile(filterSelector))
+		nil, nodes), compileMatcher(filterSelector, s))
 }
 
 // NextMatcherUntilNodes is like NextUntilNodes, with the
@@ -483,7 +536,7 @@ func (s *Selection) NextMatcherUntilNodes(filter Matcher, nodes ...*html.Node) *
 // It returns a new Selection object containing the matched elements.
 func (s *Selection) PrevFilteredUntil(filterSelector, untilSelector string) *Selection {
 	return filterAndPush(s, getSiblingNodes(s.Nodes, siblingPrevUntil,
-		cascadia.MustCompile(untilSelector), nil), cascadia.MustCompile(filterSelector))
+		compileMatcher(untilSelector, s), nil), compileMatcher(filterSelector, s))
 }
 
 // PrevFilteredUntilMatcher is like PrevUntilMatcher, with the option to filter
@@ -498,7 +551,7 @@ func (s *Selection) PrevFilteredUntilMatcher(filter, until Matcher) *Selection {
 // option to filter the results based on a selector string. It returns a new
 // Selection object containing the matched elements.
 func (s *Selection) PrevFilteredUntilSelection(filterSelector string, sel *Selection) *Selection {
-	return s.PrevMatcherUntilSelection(cascadia.MustCompile(filterSelector), sel)
+	return s.PrevMatcherUntilSelection(compileMatcher(filterSelector, s), sel)
 }
 
 // PrevMatcherUntilSelection is like PrevUntilSelection, with the
@@ -516,7 +569,7 @@ func (s *Selection) PrevMatcherUntilSelection(filter Matcher, sel *Selection) *S
 // Selection object containing the matched elements.
 func (s *Selection) PrevFilteredUntilNodes(filterSelector string, nodes ...*html.Node) *Selection {
 	return filterAndPush(s, getSiblingNodes(s.Nodes, siblingPrevUntil,
-		nil, nodes), cascadia.MustCompile(filterSelector))
+		nil, nodes), compileMatcher(filterSelector, s))
 }
 
 // PrevMatcherUntilNodes is like PrevUntilNodes, with the
@@ -546,10 +599,44 @@ func findWithMatcher(nodes []*html.Node, m Matcher) []*html.Node {
 				result = append(result, m.MatchAll(c)...)
 			}
 		}
+		// A relative selector may match the following siblings
+		for _, c := range followingSearchRoots(n, m) {
+			result = append(result, m.MatchAll(c)...)
+		}
 		return
 	})
 }
 
+// Internal implementation of FindN that walks the descendants of the nodes
+// and stops as soon as max matches are found.
+func findNWithMatcher(nodes []*html.Node, m Matcher, max int) (result []*html.Node) {
+	if max <= 0 {
+		return nil
+	}
+	add := func(n *html.Node) bool {
+		if n.Type == html.ElementNode && m.Match(n) && !isInSlice(result, n) {
+			result = append(result, n)
+		}
+		return len(result) == max
+	}
+	for _, root := range nodes {
+		for n := nextInTree(root, root); n != nil; n = nextInTree(n, root) {
+			if add(n) {
+				return
+			}
+		}
+		// A relative selector may match the following siblings
+		for _, sib := range followingSearchRoots(root, m) {
+			for n := sib; n != nil; n = nextInTree(n, sib) {
+				if add(n) {
+					return
+				}
+			}
+		}
+	}
+	return
+}
+
 // Internal implementation to get all parent nodes, stopping at the specified
 // node (or nil if no stop).
 func getParentsNodes(nodes []*html.Node, stopm Matcher, stopNodes []*html.Node) []*html.Node {
@@ -31,6 +31,77 @@ func TestChainedFind(t *testing.T) {
 	assertLength(t, sel.Nodes, 4)
 }
 
+func TestFindFirst(t *testing.T) {
+	sel := Doc().Find("div.row-fluid")
+	first := Doc().FindFirst("div.row-fluid")
+	assertLength(t, first.Nodes, 1)
+	if first.Get(0) != sel.Get(0) {
+		t.Errorf("Expected FindFirst to return %+v, got %+v.", sel.Get(0), first.Get(0))
+	}
+}
+
+func TestFindFirstNone(t *testing.T) {
+	sel := Doc().FindFirst("zzzz")
+	assertLength(t, sel.Nodes, 0)
+}
+
+func TestFindFirstRollback(t *testing.T) {
+	sel := Doc().Find("div.row-fluid")
+	sel2 := sel.FindFirst("a").End()
+	assertEqual(t, sel, sel2)
+}
+
+func TestFindN(t *testing.T) {
+	exp := Doc().Find("div.hero-unit").Find(".row-fluid")
+	sel := Doc().Find("div.hero-unit").FindN(".row-fluid", 3)
+	assertLength(t, sel.Nodes, 3)
+	for i, n := range sel.Nodes {
+		if n != exp.Get(i) {
+			t.Errorf("Expected node %d to be %+v, got %+v.", i, exp.Get(i), n)
+		}
+	}
+}
+
+func TestFindNMoreThanMatches(t *testing.T) {
+	sel := Doc().FindN("div.row-fluid", 100)
+	assertLength(t, sel.Nodes, 9)
+}
+
+func TestFindNZero(t *testing.T) {
+	sel := Doc().FindN("div.row-fluid", 0)
+	assertLength(t, sel.Nodes, 0)
+}
+
+func TestFindNNested(t *testing.T) {
+	// The .pvk-content nodes contain the .row-fluid ones, make sure
+	// no duplicates are returned.
+	sel := Doc().Find(".pvk-content, .pvk-content .row-fluid").FindN("div", 100)
+	exp := Doc().Find(".pvk-content, .pvk-content .row-fluid").Find("div")
+	assertLength(t, sel.Nodes, exp.Length())
+}
+
+func TestFindNNotSelf(t *testing.T) {
+	sel := Doc().Find("h1").FindN("h1", 1)
+	assertLength(t, sel.Nodes, 0)
+}
+
+func TestExists(t *testing.T) {
+	if !Doc().Exists("div.row-fluid") {
+		t.Error("Expected div.row-fluid to exist.")
+	}
+	if Doc().Exists("zzzz") {
+		t.Error("Expected zzzz to not exist.")
+	}
+	if Doc().Find("h1").Exists("h1") {
+		t.Error("Expected h1 to not exist within h1.")
+	}
+}
+
+func TestExistsInvalidSelector(t *testing.T) {
+	defer assertPanic(t)
+	Doc().Exists(":+ ^")
+}
+
 func TestChildren(t *testing.T) {
 	sel := Doc().Find(".pvk-content").Children()
 	assertLength(t, sel.Nodes, 5)
@@ -0,0 +1,264 @@
+package goquery
+
+import (
+	"errors"
+
+	"golang.org/x/net/html"
+)
+
+// ErrTxDone is returned by Tx.Commit and Tx.Rollback when the transaction has
+// already been committed or rolled back, either directly or by finishing an
+// enclosing transaction.
+var ErrTxDone = errors.New("goquery: transaction has already been committed or rolled back")
+
+// Tx is a transaction on a Document, started with Document.Begin. While it
+// is open, the changes made to the document through goquery are recorded as
+// an undo log, so that they can be reverted with Rollback instead of working
+// on a clone of the document.
+type Tx struct {
+	doc *Document
+	ops []txOp
+}
+
+// An inverse operation of the undo log: restore the node at its position
+// (parent and next sibling, a nil parent meaning detached), its attributes
+// or its data.
+type txOp struct {
+	n      *html.Node
+	kind   txOpKind
+	parent *html.Node
+	next   *html.Node
+	attr   []html.Attribute
+	data   string
+}
+
+type txOpKind int
+
+const (
+	txPosition txOpKind = iota
+	txAttr
+	txData
+)
+
+// Begin starts a transaction on the document. Until it is committed or
+// rolled back, the manipulation methods (manipulation.go, including SetText)
+// and the attribute, class, style, data and property setters (property.go)
+// called on Selections of this document record the inverse of the changes
+// they make. This includes the nodes of other documents moved into this
+// one. Changes made directly to the html.Node values, or through a
+// Selection of another Document, are not recorded.
+//
+// If a transaction is already open, Begin starts a nested transaction: its
+// changes are merged into the enclosing transaction when it is committed,
+// and only its own changes are reverted when it is rolled back.
+func (d *Document) Begin() *Tx {
+	tx := &Tx{doc: d}
+	d.txs = append(d.txs, tx)
+	return tx
+}
+
+// Commit ends the transaction, keeping its changes. If the transaction is
+// nested, its changes can still be reverted by rolling back the enclosing
+// transaction. Nested transactions still open are committed as well. It
+// returns ErrTxDone if the transaction is already finished.
+func (tx *Tx) Commit() error {
+	i := tx.index()
+	if i < 0 {
+		return ErrTxDone
+	}
+
+	d := tx.doc
+	if i > 0 {
+		parent := d.txs[i-1]
+		for _, t := range d.txs[i:] {
+			parent.ops = append(parent.ops, t.ops...)
+		}
+	}
+	d.endTxs(i)
+	return nil
+}
+
+// Rollback ends the transaction, reverting its changes in reverse order, so
+// that the document is back to its state when the transaction started. The
+// same html.Node values are put back in place, so Selections made before the
+// transaction can still be used. Nested transactions still open are rolled
+// back as well. It returns ErrTxDone if the transaction is already finished.
+//
+// The reverted changes are reported to the observers of the document (see
+// Document.Observe), but not recorded by the enclosing transactions, nor are
+// the changes made by the observers during the rollback. The changes made to
+// the document without being recorded (see Document.Begin) during the
+// transaction may prevent the undo log from being replayed correctly.
+func (tx *Tx) Rollback() error {
+	i := tx.index()
+	if i < 0 {
+		return ErrTxDone
+	}
+
+	d := tx.doc
+	var ops []txOp
+	for _, t := range d.txs[i:] {
+		ops = append(ops, t.ops...)
+	}
+	d.endTxs(i)
+
+	// The enclosing transactions are hidden while the undo log is replayed
+	txs := d.txs
+	d.txs = nil
+	defer func() { d.txs = txs }()
+	for k := len(ops) - 1; k >= 0; k-- {
+		d.undo(ops[k])
+	}
+	return nil
+}
+
+// Returns the position of the transaction in the document's stack of open
+// transactions, or -1 if it is finished.
+func (tx *Tx) index() int {
+	for i, t := range tx.doc.txs {
+		if t == tx {
+			return i
+		}
+	}
+	return -1
+}
+
+// Removes the transactions from index i in the stack of open transactions.
+func (d *Document) endTxs(i int) {
+	for j := i; j < len(d.txs); j++ {
+		d.txs[j].ops = nil
+		d.txs[j] = nil
+	}
+	d.txs = d.txs[:i]
+}
+
+// Reverts the change of the operation, reporting it to the observers.
+func (d *Document) undo(op txOp) {
+	switch op.kind {
+	case txAttr:
+		d.changeAttrs(op.n, func() { op.n.Attr = op.attr })
+	case txData:
+		d.setData(op.n, op.data)
+	default:
+		d.removeChild(op.n)
+		if op.parent != nil {
+			d.insertChild(op.parent, op.n, op.next)
+		}
+	}
+}
+
+// Returns the innermost open transaction, or nil if there is none. The
+// document may be nil, for Selections not tied to a document.
+func (d *Document) currentTx() *Tx {
+	if d == nil || len(d.txs) == 0 {
+		return nil
+	}
+	return d.txs[len(d.txs)-1]
+}
+
+// The tree, attribute and data changes made by the manipulation and
+// property methods all go through the following functions, so that they can
+// be recorded, reported to the observers and drop the cached cascade.
+
+// Removes the node from its parent, if it has one.
+func (d *Document) removeChild(n *html.Node) {
+	p := n.Parent
+	if p == nil {
+		return
+	}
+	prev, next := n.PrevSibling, n.NextSibling
+	d.recordPosition(n)
+	p.RemoveChild(n)
+	d.stylesChanged()
+
+	if d.observed() {
+		d.notify(MutationRecord{
+			Type:            MutationChildList,
+			Target:          p,
+			RemovedNodes:    []*html.Node{n},
+			PreviousSibling: prev,
+			NextSibling:     next,
+		})
+	}
+}
+
+// Inserts the node, which must have no parent, as a child of parent before
+// ref, or as its last child if ref is nil.
+func (d *Document) insertChild(parent, n, ref *html.Node) {
+	d.recordPosition(n)
+	parent.InsertBefore(n, ref)
+	d.stylesChanged()
+
+	if d.observed() {
+		d.notify(MutationRecord{
+			Type:            MutationChildList,
+			Target:          parent,
+			AddedNodes:      []*html.Node{n},
+			PreviousSibling: n.PrevSibling,
+			NextSibling:     n.NextSibling,
+		})
+	}
+}
+
+// Calls f, which may change the attributes of the node.
+func (d *Document) changeAttrs(n *html.Node, f func()) {
+	tx := d.currentTx()
+	if tx == nil && !d.observed() {
+		f()
+		d.stylesChanged()
+		return
+	}
+
+	// The attributes are copied, as f may change them in place
+	old := append([]html.Attribute(nil), n.Attr...)
+	f()
+	d.stylesChanged()
+	if equalAttrs(old, n.Attr) {
+		return
+	}
+	if tx != nil {
+		tx.ops = append(tx.ops, txOp{n: n, kind: txAttr, attr: old})
+	}
+	if d.observed() {
+		d.notifyAttrs(n, old)
+	}
+}
+
+// Sets the data of the (text or comment) node.
+func (d *Document) setData(n *html.Node, data string) {
+	old := n.Data
+	if old == data {
+		return
+	}
+	if tx := d.currentTx(); tx != nil {
+		tx.ops = append(tx.ops, txOp{n: n, kind: txData, data: old})
+	}
+	n.Data = data
+	d.stylesChanged()
+
+	if d.observed() {
+		d.notify(MutationRecord{
+			Type:     MutationCharacterData,
+			Target:   n,
+			OldValue: old,
+		})
+	}
+}
+
+func (d *Document) recordPosition(n *html.Node) {
+	if tx := d.currentTx(); tx != nil {
+		tx.ops = append(tx.ops, txOp{n: n, parent: n.Parent, next: n.NextSibling})
+	}
+}
+
+func equalAttrs(a, b []html.Attribute) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,192 @@
+package goquery
+
+import (
+	"testing"
+)
+
+func TestTxRollback(t *testing.T) {
+	doc := Doc2Clone()
+	before, _ := doc.Html()
+	main := doc.Find("#main")
+	nf1 := doc.Find("#nf1")
+
+	tx := doc.Begin()
+	doc.Find("#n1").AppendHtml("<p>new</p>")
+	doc.Find("#n2").Remove()
+	doc.Find("#n3").ReplaceWithHtml("<div id=\"r\"></div>")
+	doc.Find("#nf2").WrapAllHtml("<section><div></div></section>")
+	doc.Find("#n1").Empty()
+	doc.Find("#n5").PrependSelection(doc.Find("#nf1"))
+	doc.Find(".odd").AppendSelection(doc.Find("#nf3"))
+	doc.Find("#n6").Unwrap()
+	main.SetAttr("id", "changed").AddClass("a b").SetCss("color", "red")
+	doc.Find("#nf4").RenameAttr("id", "x").SetData("fooBar", "1")
+	doc.Find("body").RemoveAttr("class")
+	doc.Find("#nf5").SetText("text").Contents().SetText("changed")
+	if e := tx.Rollback(); e != nil {
+		t.Fatal(e)
+	}
+
+	after, _ := doc.Html()
+	if after != before {
+		t.Errorf("expected the document to be restored, got %s", after)
+	}
+	// The same nodes are back in the tree
+	if !main.IsSelection(doc.Find("#main")) || !nf1.IsSelection(doc.Find("#nf1")) {
+		t.Error("expected the original nodes to be restored")
+	}
+	if e := tx.Rollback(); e != ErrTxDone {
+		t.Errorf("expected ErrTxDone, got %v", e)
+	}
+}
+
+func TestTxCommit(t *testing.T) {
+	doc := Doc2Clone()
+
+	tx := doc.Begin()
+	doc.Find("#n1").Remove()
+	if e := tx.Commit(); e != nil {
+		t.Fatal(e)
+	}
+	if e := tx.Rollback(); e != ErrTxDone {
+		t.Errorf("expected ErrTxDone, got %v", e)
+	}
+	assertLength(t, doc.Find("#n1").Nodes, 0)
+
+	// Not recorded anymore
+	doc.Find("#n2").Remove()
+	if len(doc.txs) != 0 {
+		t.Errorf("expected no open transaction, got %d", len(doc.txs))
+	}
+}
+
+func TestTxNested(t *testing.T) {
+	doc := Doc2Clone()
+	before, _ := doc.Html()
+
+	outer := doc.Begin()
+	doc.Find("#n1").Remove()
+	inner := doc.Begin()
+	doc.Find("#n2").SetAttr("title", "x")
+	if e := inner.Rollback(); e != nil {
+		t.Fatal(e)
+	}
+	assertLength(t, doc.Find("#n1").Nodes, 0)
+	assertLength(t, doc.Find("#n2[title]").Nodes, 0)
+
+	inner = doc.Begin()
+	doc.Find("#n3").Remove()
+	if e := inner.Commit(); e != nil {
+		t.Fatal(e)
+	}
+	// An open nested transaction is rolled back with the enclosing one
+	doc.Begin()
+	doc.Find("#n4").Remove()
+	if e := outer.Rollback(); e != nil {
+		t.Fatal(e)
+	}
+
+	after, _ := doc.Html()
+	if after != before {
+		t.Errorf("expected the document to be restored, got %s", after)
+	}
+	if len(doc.txs) != 0 {
+		t.Errorf("expected no open transaction, got %d", len(doc.txs))
+	}
+}
+
+func TestTxRollbackOtherDocument(t *testing.T) {
+	doc := Doc2Clone()
+	other := Doc2Clone()
+	before, _ := doc.Html()
+	otherBefore, _ := other.Html()
+
+	tx := doc.Begin()
+	doc.Find("#n1").AppendSelection(other.Find("#nf1"))
+	if e := tx.Rollback(); e != nil {
+		t.Fatal(e)
+	}
+
+	if h, _ := doc.Html(); h != before {
+		t.Errorf("expected the document to be restored, got %s", h)
+	}
+	if h, _ := other.Html(); h != otherBefore {
+		t.Errorf("expected the other document to be restored, got %s", h)
+	}
+}
+
+func TestTxRollbackProp(t *testing.T) {
+	doc := loadString(t, formHtml)
+	before, _ := doc.Html()
+
+	tx := doc.Begin()
+	doc.Find("input[type=radio]").Last().SetProp("checked", true)
+	doc.Find("#o2").SetProp("selected", true)
+	doc.Find("input").RemoveAttrs("name", "value")
+	if e := tx.Rollback(); e != nil {
+		t.Fatal(e)
+	}
+
+	if h, _ := doc.Html(); h != before {
+		t.Errorf("expected the document to be restored, got %s", h)
+	}
+}
+
+func TestTxRollbackObserved(t *testing.T) {
+	doc := loadString(t, `<div id="a" class="x"><p>old</p></div>`)
+	p := doc.Find("p")
+
+	tx := doc.Begin()
+	doc.Find("#a").AddClass("y")
+	p.Contents().SetText("new")
+	p.Remove()
+
+	var recs []MutationRecord
+	doc.Observe(func(r MutationRecord) {
+		recs = append(recs, r)
+	})
+	if e := tx.Rollback(); e != nil {
+		t.Fatal(e)
+	}
+
+	types := []MutationType{MutationChildList, MutationCharacterData, MutationAttributes}
+	if len(recs) != len(types) {
+		t.Fatalf("expected %d records, got %d", len(types), len(recs))
+	}
+	for i, typ := range types {
+		if recs[i].Type != typ {
+			t.Errorf("%d: expected %s, got %s", i, typ, recs[i].Type)
+		}
+	}
+	if r := recs[0]; len(r.AddedNodes) != 1 || r.AddedNodes[0] != p.Get(0) {
+		t.Errorf("expected the paragraph to be added back, got %+v", r)
+	}
+	if r := recs[1]; r.OldValue != "new" {
+		t.Errorf("expected old value new, got %q", r.OldValue)
+	}
+	if r := recs[2]; r.AttributeName != "class" || r.OldValue != "x y" {
+		t.Errorf("expected old class x y, got %+v", r)
+	}
+}
+
+func TestTxRollbackNestedNotRecorded(t *testing.T) {
+	doc := Doc2Clone()
+	before, _ := doc.Html()
+
+	outer := doc.Begin()
+	doc.Find("#n1").SetAttr("id", "x")
+	inner := doc.Begin()
+	doc.Find("#n2").Remove()
+	if e := inner.Rollback(); e != nil {
+		t.Fatal(e)
+	}
+	if len(outer.ops) != 1 {
+		t.Errorf("expected the rollback not to be recorded, got %d operations", len(outer.ops))
+	}
+	if e := outer.Rollback(); e != nil {
+		t.Fatal(e)
+	}
+	if h, _ := doc.Html(); h != before {
+		t.Errorf("expected the document to be restored, got %s", h)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"sync"
 
 	"golang.org/x/net/html"
 )
@@ -16,8 +17,17 @@ import (
 // document node to manipulate, and can make selections on this document.
 type Document struct {
 	*Selection
-	Url      *url.URL
-	rootNode *html.Node
+	Url       *url.URL
+	rootNode  *html.Node
+	txs       []*Tx
+	observers []*Observer
+	// The options and the cached cascade of ComputedStyle, and the number of
+	// times the cascade was dropped, so that a cascade built meanwhile is not
+	// kept
+	styleMu   sync.Mutex
+	styleOpts StyleOptions
+	cascade   *cascade
+	styleGen  int
 }
 
 // NewDocumentFromNode is a Document constructor that takes a root html Node
@@ -79,7 +89,7 @@ func CloneDocument(doc *Document) *Document {
 // Private constructor, make sure all fields are correctly filled.
 func newDocument(root *html.Node, url *url.URL) *Document {
 	// Create and fill the document
-	d := &Document{nil, url, root}
+	d := &Document{Url: url, rootNode: root}
 	d.Selection = newSingleSelection(root, d)
 	return d
 }
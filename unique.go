@@ -0,0 +1,253 @@
+package goquery
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	"code.google.com/p/cascadia"
+	"golang.org/x/net/html"
+)
+
+// UniqueSelectorOptions configures Selection.UniqueSelector.
+type UniqueSelectorOptions struct {
+	// IsStable reports whether an id or a class name is stable enough to be
+	// used in the selector. If nil, the names looking generated are avoided:
+	// the ones with 3 digits or more, or with a prefix used by CSS-in-JS
+	// libraries (css-, sc-, jsx-, svelte-).
+	IsStable func(name string) bool
+	// Attrs are the attributes that can be used in the selector, in order of
+	// preference. If nil, DefaultUniqueSelectorAttrs is used.
+	Attrs []string
+}
+
+// DefaultUniqueSelectorAttrs are the attributes used by UniqueSelector when
+// UniqueSelectorOptions.Attrs is nil.
+var DefaultUniqueSelectorAttrs = []string{"data-testid", "name", "role", "aria-label",
+	"type", "for", "title", "alt", "href", "src"}
+
+// UniqueSelector returns a CSS selector, as short as possible, that matches
+// the first node of the Selection, and only this node, in its document. It
+// returns an empty string if the Selection is empty or the first node is not
+// an element.
+//
+// For the node and then for each of its ancestors, if required, the selector
+// tries a stable id, the tag name, the tag name with stable classes, with one
+// of the attributes of the options, and then with :nth-child, in this order
+// of preference, until the node is identified. The ancestors are joined with
+// the child combinator (>). A Find of the selector from the Document returns
+// the node.
+func (s *Selection) UniqueSelector(opts UniqueSelectorOptions) string {
+	if len(s.Nodes) == 0 || s.Nodes[0].Type != html.ElementNode {
+		return ""
+	}
+	if opts.IsStable == nil {
+		opts.IsStable = isStableName
+	}
+	if opts.Attrs == nil {
+		opts.Attrs = DefaultUniqueSelectorAttrs
+	}
+
+	target := s.Nodes[0]
+	root := target
+	for root.Parent != nil {
+		root = root.Parent
+	}
+
+	// matchesOnly checks if the selector matches the target only
+	matchesOnly := func(sel string) bool {
+		m, e := cascadia.Compile(sel)
+		if e != nil {
+			return false
+		}
+		ns := m.MatchAll(root)
+		return len(ns) == 1 && ns[0] == target
+	}
+
+	var steps []string
+	for n := target; n != nil && n.Type == html.ElementNode; n = n.Parent {
+		rest := ""
+		if len(steps) > 0 {
+			rest = " > " + strings.Join(steps, " > ")
+		}
+
+		// The first candidate identifying the target ends the selector,
+		// otherwise the first one unique among the siblings is kept and the
+		// parent is tried.
+		var step string
+		for _, c := range uniqueSelectorCandidates(n, opts) {
+			if matchesOnly(c + rest) {
+				return c + rest
+			}
+			if step == "" && isUniqueAmongSiblings(n, c) {
+				step = c
+			}
+		}
+		if step == "" {
+			step = "*:nth-child(" + strconv.Itoa(elementIndex(n)) + ")"
+		}
+		steps = append([]string{step}, steps...)
+	}
+
+	return strings.Join(steps, " > ")
+}
+
+// Path returns the full CSS selector path of the first node of the Selection,
+// from the root element, such as html > body > div:nth-child(2) > p. Each
+// element is identified among its siblings by its tag name, followed by
+// :nth-child when its siblings include elements of the same tag name. The
+// elements that can't be matched by their tag name, such as the camelCase
+// SVG elements (clipPath), are identified by *:nth-child. It returns an empty
+// string if the Selection is empty or the first node is not an element.
+func (s *Selection) Path() string {
+	if len(s.Nodes) == 0 || s.Nodes[0].Type != html.ElementNode {
+		return ""
+	}
+
+	var steps []string
+	for n := s.Nodes[0]; n != nil && n.Type == html.ElementNode; n = n.Parent {
+		step := typeSelector(n)
+		if step == "*" || !isUniqueAmongSiblings(n, step) {
+			step += ":nth-child(" + strconv.Itoa(elementIndex(n)) + ")"
+		}
+		steps = append([]string{step}, steps...)
+	}
+	return strings.Join(steps, " > ")
+}
+
+// Returns the candidate compound selectors for the element, in order of
+// preference.
+func uniqueSelectorCandidates(n *html.Node, opts UniqueSelectorOptions) []string {
+	var cands []string
+	if id, ok := getAttributeValue("id", n); ok && id != "" && opts.IsStable(id) {
+		cands = append(cands, "#"+cssEscape(id))
+	}
+
+	tag := typeSelector(n)
+	cands = append(cands, tag)
+
+	classes, _ := getClassesAndAttr(n, false)
+	var stable []string
+	for _, cl := range classes {
+		if opts.IsStable(cl) {
+			stable = append(stable, "."+cssEscape(cl))
+		}
+	}
+	for _, cl := range stable {
+		cands = append(cands, tag+cl)
+	}
+	if len(stable) > 1 {
+		cands = append(cands, tag+strings.Join(stable, ""))
+	}
+
+	for _, name := range opts.Attrs {
+		if val, ok := getAttributeValue(name, n); ok {
+			cands = append(cands, tag+"["+cssEscape(name)+"="+cssQuote(val)+"]")
+		}
+	}
+
+	return append(cands, tag+":nth-child("+strconv.Itoa(elementIndex(n))+")")
+}
+
+// Checks if the compound selector matches the element and none of its
+// sibling elements.
+func isUniqueAmongSiblings(n *html.Node, sel string) bool {
+	m, e := cascadia.Compile(sel)
+	if e != nil || !m.Match(n) {
+		return false
+	}
+	if n.Parent == nil {
+		return true
+	}
+	for c := n.Parent.FirstChild; c != nil; c = c.NextSibling {
+		if c != n && c.Type == html.ElementNode && m.Match(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns the type selector of the element, or the universal selector if
+// its tag name is not in lowercase, as the type selectors are lowercased
+// and would not match the camelCase SVG elements, such as clipPath.
+func typeSelector(n *html.Node) string {
+	for i := 0; i < len(n.Data); i++ {
+		if c := n.Data[i]; c >= 'A' && c <= 'Z' {
+			return "*"
+		}
+	}
+	return cssEscape(n.Data)
+}
+
+// Returns the position of the element among its sibling elements, starting
+// at 1, as used by :nth-child.
+func elementIndex(n *html.Node) int {
+	i := 1
+	for c := n.PrevSibling; c != nil; c = c.PrevSibling {
+		if c.Type == html.ElementNode {
+			i++
+		}
+	}
+	return i
+}
+
+func isStableName(name string) bool {
+	for _, prefix := range []string{"css-", "sc-", "jsx-", "svelte-"} {
+		if strings.HasPrefix(name, prefix) {
+			return false
+		}
+	}
+	digits := 0
+	for i := 0; i < len(name); i++ {
+		if name[i] >= '0' && name[i] <= '9' {
+			digits++
+		}
+	}
+	return digits < 3
+}
+
+// Escapes the name to be used as a CSS identifier, as per CSS.escape.
+func cssEscape(name string) string {
+	var buf bytes.Buffer
+	for i, r := range name {
+		switch {
+		case r == 0:
+			buf.WriteRune('\uFFFD')
+		case r >= '0' && r <= '9' && (i == 0 || (i == 1 && name[0] == '-')):
+			// A leading digit is escaped as a code point
+			buf.WriteString("\\" + strconv.FormatInt(int64(r), 16) + " ")
+		case r == '-' && i == 0 && len(name) == 1:
+			buf.WriteString("\\-")
+		case r >= 0x80 || r == '-' || r == '_' ||
+			(r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z'):
+			buf.WriteRune(r)
+		case r < 0x20 || r == 0x7f:
+			buf.WriteString("\\" + strconv.FormatInt(int64(r), 16) + " ")
+		default:
+			buf.WriteByte('\\')
+			buf.WriteRune(r)
+		}
+	}
+	return buf.String()
+}
+
+// Quotes the value to be used as a CSS string.
+func cssQuote(val string) string {
+	var buf bytes.Buffer
+	buf.WriteByte('"')
+	for _, r := range val {
+		switch {
+		case r == 0:
+			buf.WriteRune('\uFFFD')
+		case r == '"' || r == '\\':
+			buf.WriteByte('\\')
+			buf.WriteRune(r)
+		case r < 0x20 || r == 0x7f:
+			buf.WriteString("\\" + strconv.FormatInt(int64(r), 16) + " ")
+		default:
+			buf.WriteRune(r)
+		}
+	}
+	buf.WriteByte('"')
+	return buf.String()
+}
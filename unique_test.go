@@ -0,0 +1,85 @@
+package goquery
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUniqueSelector(t *testing.T) {
+	doc := loadString(t, `<div id="main"><p class="intro css-1a2b3c">a</p><p class="intro">b</p>
+<form><input name="q"><input type="submit"></form><div id="x123456"><span>c</span></div></div>`)
+
+	cases := []struct {
+		sel      string
+		expected string
+	}{
+		{"#main", "#main"},
+		{"p:first-child", "p:nth-child(1)"},
+		{"form", "form"},
+		{"input[name]", `input[name="q"]`},
+		{"input[type]", `input[type="submit"]`},
+		{"span", "span"},
+		{"#x123456", "div:nth-child(4)"},
+	}
+	for _, c := range cases {
+		if got := doc.Find(c.sel).UniqueSelector(UniqueSelectorOptions{}); got != c.expected {
+			t.Errorf("%s: expected %q, got %q", c.sel, c.expected, got)
+		}
+	}
+
+	opts := UniqueSelectorOptions{IsStable: func(string) bool { return true }}
+	if got := doc.Find("#x123456").UniqueSelector(opts); got != "#x123456" {
+		t.Errorf("expected the id to be used, got %q", got)
+	}
+	if got := doc.Find("none").UniqueSelector(opts); got != "" {
+		t.Errorf("expected an empty selector, got %q", got)
+	}
+}
+
+func TestUniqueSelectorEscape(t *testing.T) {
+	doc := loadString(t, `<p id="1st">a</p><p class="a:b">b</p><p title='say "hi"'>c</p>`)
+	for _, n := range doc.Find("p").Nodes {
+		sel := newSingleSelection(n, doc)
+		got := sel.UniqueSelector(UniqueSelectorOptions{})
+		if !doc.Find(got).IsSelection(sel) {
+			t.Errorf("%s does not match the node only", got)
+		}
+	}
+}
+
+func TestPath(t *testing.T) {
+	doc := Doc2()
+	if got := doc.Find("#n2").Path(); got != "html > body > div:nth-child(1) > div:nth-child(2)" {
+		t.Errorf("unexpected path %q", got)
+	}
+	if got := doc.Find("body").Contents().First().Path(); got != "" {
+		t.Errorf("expected an empty path for a text node, got %q", got)
+	}
+}
+
+func TestUniqueSelectorRoundTrip(t *testing.T) {
+	for _, doc := range []*Document{Doc(), Doc2(), Doc3()} {
+		doc.Find("*").Each(func(i int, sel *Selection) {
+			for _, got := range []string{sel.UniqueSelector(UniqueSelectorOptions{}), sel.Path()} {
+				if !doc.Find(got).IsSelection(sel) {
+					t.Errorf("%s does not match %s only", got, sel.Path())
+				}
+			}
+		})
+	}
+}
+
+func TestUniqueSelectorSVG(t *testing.T) {
+	doc := loadString(t, `<svg><defs></defs><clipPath id="c1"></clipPath><clipPath></clipPath><linearGradient></linearGradient><g><clipPath></clipPath></g></svg>`)
+
+	if got := doc.Find("svg").Children().Eq(2).Path(); got != "html > body > svg > *:nth-child(3)" {
+		t.Errorf("unexpected path %q", got)
+	}
+	doc.Find("svg *").Each(func(i int, sel *Selection) {
+		for _, got := range []string{sel.UniqueSelector(UniqueSelectorOptions{}), sel.Path()} {
+			if strings.HasSuffix(got, "> ") || !doc.Find(got).IsSelection(sel) {
+				t.Errorf("%q does not match %s only", got, sel.Path())
+			}
+		}
+	})
+}
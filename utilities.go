@@ -11,6 +11,20 @@ func getChildren(n *html.Node) (result []*html.Node) {
 	return result
 }
 
+// Returns the node following n in a depth-first, pre-order walk of the
+// subtree rooted at root, or nil when the walk is over.
+func nextInTree(n, root *html.Node) *html.Node {
+	if n.FirstChild != nil {
+		return n.FirstChild
+	}
+	for ; n != root; n = n.Parent {
+		if n.NextSibling != nil {
+			return n.NextSibling
+		}
+	}
+	return nil
+}
+
 // Loop through all container nodes to search for the target node.
 func sliceContains(container []*html.Node, contained *html.Node) bool {
 	for _, n := range container {
@@ -0,0 +1,311 @@
+package goquery
+
+import (
+	"bytes"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// VisibilityOptions configures the visibility heuristics of
+// FilterVisibleWith, NotHiddenWith, VisibleTextWith and the :visible
+// pseudo-class (see RegisterVisible).
+type VisibilityOptions struct {
+	// HiddenClasses are the class names of the elements hidden by the style
+	// sheets of the site, such as sr-only.
+	HiddenClasses []string
+	// IsHidden, if not nil, is called for the elements that the other rules
+	// don't hide, and reports whether the element is hidden.
+	IsHidden func(n *html.Node) bool
+}
+
+// The options of FilterVisible, NotHidden and VisibleText.
+func defaultVisibilityOptions() VisibilityOptions {
+	return VisibilityOptions{HiddenClasses: []string{"sr-only", "visually-hidden"}}
+}
+
+// The elements that are never rendered.
+var hiddenElements = map[string]bool{
+	"area":     true,
+	"base":     true,
+	"basefont": true,
+	"datalist": true,
+	"head":     true,
+	"link":     true,
+	"meta":     true,
+	"noembed":  true,
+	"noframes": true,
+	"param":    true,
+	"rp":       true,
+	"script":   true,
+	"style":    true,
+	"template": true,
+	"title":    true,
+}
+
+func init() {
+	RegisterVisible(DefaultSelectorRegistry, defaultVisibilityOptions())
+}
+
+// RegisterVisible registers the :visible pseudo-class in the registry,
+// matching the elements that FilterVisibleWith keeps with the options. It is
+// registered in DefaultSelectorRegistry with the options of FilterVisible.
+// The style sheets and the StyleOptions of the Document of the Selection
+// whose method compiles the selector, such as Find, are used for the nodes
+// of its tree. For the other nodes, and the selectors compiled by Compile,
+// only the inline styles are considered. It returns the registry so that
+// calls can be chained.
+func RegisterVisible(reg *SelectorRegistry, opts VisibilityOptions) *SelectorRegistry {
+	return reg.registerDoc("visible", func(doc *Document) PseudoClassFunc {
+		v := newVisibility(doc, opts)
+		return func(n *html.Node, arg string) bool {
+			return v.isVisible(n)
+		}
+	})
+}
+
+// FilterVisible reduces the set of matched nodes to the visible ones, as
+// judged by heuristics on the nodes and their ancestors. It is like
+// FilterVisibleWith with the hidden classes sr-only and visually-hidden.
+func (s *Selection) FilterVisible() *Selection {
+	return s.FilterVisibleWith(defaultVisibilityOptions())
+}
+
+// FilterVisibleWith reduces the set of matched nodes to the visible ones, as
+// judged by heuristics on the nodes and their ancestors. An element is
+// hidden if it or one of its ancestors:
+//
+//   - has the hidden attribute, or the aria-hidden attribute set to true;
+//   - is an element that is never rendered, such as <head>, <script>,
+//     <style> or <template>, or a closed <dialog>;
+//   - is an <input type="hidden">, or a <noscript> unless scripting is
+//     disabled in the StyleOptions of the Document;
+//   - has display: none;
+//   - has one of the hidden classes, or is hidden by the IsHidden hook of
+//     the options.
+//
+// An element is also hidden if its visibility is hidden or collapse, which
+// its descendants inherit unless they override it. If the Document of the
+// Selection has style sheets, the display and visibility of its nodes are
+// the ones of ComputedStyle, otherwise the ones of the inline styles (the
+// style attributes). A text node is visible if its parent is, and the other
+// nodes, such as comments, are hidden, except the document node.
+func (s *Selection) FilterVisibleWith(opts VisibilityOptions) *Selection {
+	v := newVisibility(s.document, opts)
+	var result []*html.Node
+	for _, n := range s.Nodes {
+		if v.isVisible(n) {
+			result = append(result, n)
+		}
+	}
+	return pushStack(s, result)
+}
+
+// NotHidden removes the hidden elements from the Selection, like
+// NotHiddenWith with the options of FilterVisible.
+func (s *Selection) NotHidden() *Selection {
+	return s.NotHiddenWith(defaultVisibilityOptions())
+}
+
+// NotHiddenWith removes the elements hidden by their own attributes, tag
+// name, style or class names from the Selection, as judged by the rules of
+// FilterVisibleWith, but without considering their ancestors. The other
+// nodes are kept. It returns a new Selection object with the hidden elements
+// removed.
+func (s *Selection) NotHiddenWith(opts VisibilityOptions) *Selection {
+	v := newVisibility(s.document, opts)
+	var result []*html.Node
+	for _, n := range s.Nodes {
+		if n.Type != html.ElementNode || !v.hidesElement(n) && !isHiddenVisibility(v.ownVisibility(n)) {
+			result = append(result, n)
+		}
+	}
+	return pushStack(s, result)
+}
+
+// VisibleText gets the combined text contents of each visible node in the
+// set of matched nodes, like VisibleTextWith with the options of
+// FilterVisible.
+func (s *Selection) VisibleText() string {
+	return s.VisibleTextWith(defaultVisibilityOptions())
+}
+
+// VisibleTextWith gets the combined text contents of each visible node in
+// the set of matched nodes, including their descendants, like Text, but
+// without the text of the hidden nodes, as judged by the rules of
+// FilterVisibleWith.
+func (s *Selection) VisibleTextWith(opts VisibilityOptions) string {
+	v := newVisibility(s.document, opts)
+	var buf bytes.Buffer
+	for _, n := range s.Nodes {
+		hidden, hiddenVis := v.visibility(n)
+		if !hidden {
+			v.writeVisibleText(&buf, n, hiddenVis)
+		}
+	}
+	return buf.String()
+}
+
+// The visibility heuristics applied to the nodes of a document.
+type visibility struct {
+	opts VisibilityOptions
+	doc  *Document
+}
+
+func newVisibility(doc *Document, opts VisibilityOptions) *visibility {
+	return &visibility{opts: opts, doc: doc}
+}
+
+// Returns the cascade of the document if it has style sheets and the node
+// is in its tree, or nil if only the inline styles apply.
+func (v *visibility) cascadeFor(n *html.Node) *cascade {
+	if v.doc == nil {
+		return nil
+	}
+	root := n
+	for root.Parent != nil {
+		root = root.Parent
+	}
+	if root != v.doc.rootNode {
+		return nil
+	}
+	if c := v.doc.cascadeFor(n); c.authored {
+		return c
+	}
+	return nil
+}
+
+func (v *visibility) isVisible(n *html.Node) bool {
+	hidden, hiddenVis := v.visibility(n)
+	return !hidden && !hiddenVis
+}
+
+// Returns whether the node is hidden by itself or its ancestors, and whether
+// it is hidden by an inherited visibility, which its descendants may
+// override.
+func (v *visibility) visibility(n *html.Node) (hidden, hiddenVis bool) {
+	switch n.Type {
+	case html.DocumentNode:
+		return false, false
+	case html.TextNode:
+		n = n.Parent
+		if n == nil || n.Type == html.DocumentNode {
+			return false, false
+		}
+	case html.ElementNode:
+	default:
+		return true, false
+	}
+
+	for e := n; e != nil && e.Type == html.ElementNode; e = e.Parent {
+		if v.hidesElement(e) {
+			return true, false
+		}
+	}
+	if c := v.cascadeFor(n); c != nil {
+		return false, isHiddenVisibility(strings.ToLower(c.computed(n, "visibility")))
+	}
+	for e := n; e != nil && e.Type == html.ElementNode; e = e.Parent {
+		if vis := inlineVisibility(e); vis != "" {
+			return false, isHiddenVisibility(vis)
+		}
+	}
+	return false, false
+}
+
+// Checks if the element, with its descendants, is hidden by its own
+// attributes, tag name, display or class names.
+func (v *visibility) hidesElement(n *html.Node) bool {
+	if n.Namespace == "" {
+		if hiddenElements[n.Data] {
+			return true
+		}
+		switch n.Data {
+		case "input":
+			if typ, _ := getAttributeValue("type", n); strings.EqualFold(strings.TrimSpace(typ), "hidden") {
+				return true
+			}
+		case "noscript":
+			if v.doc == nil || !v.doc.StyleOptions().ScriptingDisabled {
+				return true
+			}
+		case "dialog":
+			if _, open := getAttributeValue("open", n); !open {
+				return true
+			}
+		}
+	}
+
+	if _, ok := getAttributeValue("hidden", n); ok {
+		return true
+	}
+	if val, _ := getAttributeValue("aria-hidden", n); strings.EqualFold(strings.TrimSpace(val), "true") {
+		return true
+	}
+	if c := v.cascadeFor(n); c != nil {
+		if strings.EqualFold(c.computed(n, "display"), "none") {
+			return true
+		}
+	} else {
+		decls, _ := getStyleAndAttr(n, false)
+		if i := findStyleDecl(decls, "display"); i > -1 && strings.EqualFold(decls[i].value, "none") {
+			return true
+		}
+	}
+	for _, class := range v.opts.HiddenClasses {
+		if nodeHasClass(n, class) {
+			return true
+		}
+	}
+	return v.opts.IsHidden != nil && v.opts.IsHidden(n)
+}
+
+// Returns the visibility declared for the element, in lowercase, or an
+// empty string.
+func (v *visibility) ownVisibility(n *html.Node) string {
+	if c := v.cascadeFor(n); c != nil {
+		return strings.ToLower(c.cascaded(n, "visibility"))
+	}
+	return inlineVisibility(n)
+}
+
+// Returns the visibility in the inline style of the element, in lowercase,
+// or an empty string.
+func inlineVisibility(n *html.Node) string {
+	decls, _ := getStyleAndAttr(n, false)
+	if i := findStyleDecl(decls, "visibility"); i > -1 {
+		return strings.ToLower(decls[i].value)
+	}
+	return ""
+}
+
+func isHiddenVisibility(v string) bool {
+	return v == "hidden" || v == "collapse"
+}
+
+// Writes the text of the visible descendants of the node, hiddenVis
+// indicating if the node is hidden by an inherited visibility.
+func (v *visibility) writeVisibleText(buf *bytes.Buffer, n *html.Node, hiddenVis bool) {
+	switch n.Type {
+	case html.TextNode:
+		if !hiddenVis {
+			buf.WriteString(n.Data)
+		}
+		return
+	case html.ElementNode:
+		if v.hidesElement(n) {
+			return
+		}
+		if c := v.cascadeFor(n); c != nil {
+			hiddenVis = isHiddenVisibility(strings.ToLower(c.computed(n, "visibility")))
+		} else if vis := inlineVisibility(n); vis != "" {
+			hiddenVis = isHiddenVisibility(vis)
+		}
+	case html.DocumentNode:
+	default:
+		return
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		v.writeVisibleText(buf, c, hiddenVis)
+	}
+}
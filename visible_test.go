@@ -0,0 +1,107 @@
+package goquery
+
+import (
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+const visibleHtml = `<div id="d1">a<span id="s1" hidden>b</span><span id="s2" aria-hidden="true">c</span><span id="s3" style="display: none">d</span><span id="s4" class="sr-only">e</span><input id="i1" type="hidden" value="x"><input id="i2" type="text"><noscript id="n1">f</noscript><template id="t1"><p>g</p></template><script>var h;</script><p id="p1">i<!-- c --></p></div>` +
+	`<div id="d2" style="visibility: hidden">j<span id="s5" style="visibility: visible">k</span><span id="s6" class="promo">l</span></div><dialog id="dl1">m</dialog><dialog id="dl2" open>n</dialog>`
+
+func TestFilterVisible(t *testing.T) {
+	doc := loadString(t, visibleHtml)
+
+	assertIds(t, doc.Find("[id]").FilterVisible(), "#d1", "#i2", "#p1", "#s5", "#dl2")
+	assertIds(t, doc.Find("#s1").Children().FilterVisible())
+	assertIds(t, doc.Find("#d1").Find("p:visible"), "#p1")
+	assertIds(t, doc.Find("span:visible, input:visible"), "#i2", "#s5")
+	assertIds(t, doc.Find("#s5, #s3").Filter(":visible"), "#s5")
+
+	if n := doc.Find("#p1").Contents().FilterVisible().Length(); n != 1 {
+		t.Errorf("expected the text node only, got %d nodes", n)
+	}
+	if n := doc.Find("#d2").Contents().FilterVisible().Length(); n != 1 {
+		t.Errorf("expected the overriding span only, got %d nodes", n)
+	}
+	if doc.FilterVisible().Length() != 1 {
+		t.Error("expected the document node to be visible")
+	}
+}
+
+func TestNotHidden(t *testing.T) {
+	doc := loadString(t, visibleHtml)
+
+	assertIds(t, doc.Find("#d1").Children().NotHidden(), "#i2", "#p1")
+	// The ancestors are not considered
+	assertIds(t, doc.Find("#s1, #t1, #d2 span").NotHidden(), "#s5", "#s6")
+	if n := doc.Find("#d1").Contents().NotHidden().Length(); n != 3 {
+		t.Errorf("expected the text node to be kept, got %d nodes", n)
+	}
+}
+
+func TestVisibleText(t *testing.T) {
+	doc := loadString(t, visibleHtml)
+
+	if txt := doc.Find("#d1").VisibleText(); txt != "ai" {
+		t.Errorf("expected ai, got %q", txt)
+	}
+	if txt := doc.Find("#d2").VisibleText(); txt != "k" {
+		t.Errorf("expected k, got %q", txt)
+	}
+	if txt := doc.Find("#s5, #s1, dialog").VisibleText(); txt != "kn" {
+		t.Errorf("expected kn, got %q", txt)
+	}
+	if txt := doc.Find("body").VisibleText(); txt != "aikn" {
+		t.Errorf("expected aikn, got %q", txt)
+	}
+}
+
+func TestVisibilityOptions(t *testing.T) {
+	doc := loadString(t, visibleHtml)
+	doc.SetStyleOptions(StyleOptions{ScriptingDisabled: true})
+
+	opts := VisibilityOptions{
+		HiddenClasses: []string{"promo"},
+		IsHidden: func(n *html.Node) bool {
+			return n.Data == "p"
+		},
+	}
+	assertIds(t, doc.Find("#d1 > *, #d2 > *").FilterVisibleWith(opts), "#s4", "#i2", "#n1", "#s5")
+	assertIds(t, doc.Find("#d1 > *, #d2 > *").NotHiddenWith(opts), "#s4", "#i2", "#n1", "#s5")
+	if txt := doc.Find("#d1").VisibleTextWith(opts); txt != "aef" {
+		t.Errorf("expected aef, got %q", txt)
+	}
+
+	// The matchers compiled by Compile have no document, so scripting is
+	// enabled
+	reg := RegisterVisible(NewSelectorRegistry(), opts)
+	assertIds(t, doc.FindMatcher(reg.MustCompile("#d1 > :visible")), "#s4", "#i2")
+}
+
+func TestVisibleStylesheets(t *testing.T) {
+	doc := loadString(t, `<style>.promo { display: none } #d2 { visibility: hidden } #d2 .shown { visibility: visible }</style>`+
+		`<div id="d1"><p id="p1" class="promo" style="display: block !important">a</p><p id="p2" class="promo">b</p><p id="p3" style="display: none">c</p></div>`+
+		`<div id="d2">d<span id="s1">e</span><span id="s2" class="shown">f</span></div>`)
+
+	assertIds(t, doc.Find("p, span").FilterVisible(), "#p1", "#s2")
+	assertIds(t, doc.Find("p, span").NotHidden(), "#p1", "#s1", "#s2")
+	assertIds(t, doc.Find("#d2").NotHidden())
+	if txt := doc.Find("body").VisibleText(); txt != "af" {
+		t.Errorf("expected af, got %q", txt)
+	}
+
+	assertIds(t, doc.Find("p:visible, span:visible"), "#p1", "#s2")
+
+	// The :visible pseudo-class uses the document of each Selection
+	other := loadString(t, `<style>.promo { color: red }</style><p class="promo">a</p>`)
+	if other.Find("p:visible").Length() != 1 {
+		t.Error("expected the paragraph of the other document to be visible")
+	}
+	assertIds(t, doc.Find("p:visible"), "#p1")
+
+	// The changes of the style sheets are taken into account
+	doc.Find("style").SetText(".promo { color: red }")
+	assertIds(t, doc.Find("p, span").FilterVisible(), "#p1", "#p2", "#s1", "#s2")
+	assertIds(t, doc.Find("p:visible"), "#p1", "#p2")
+}